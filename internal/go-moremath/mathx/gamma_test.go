@@ -0,0 +1,41 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mathx
+
+import (
+	"math"
+	"testing"
+
+	. "rsc.io/benchstat/internal/go-moremath/internal/mathtest"
+)
+
+func TestGammaInc(t *testing.T) {
+	// P(1, x) = 1 - e⁻ˣ.
+	WantFunc(t, "P(1, %v)",
+		func(x float64) float64 { return GammaInc(1, x) },
+		map[float64]float64{
+			0:   0,
+			0.5: 1 - math.Exp(-0.5),
+			1:   1 - math.Exp(-1),
+			3:   1 - math.Exp(-3),
+			10:  1 - math.Exp(-10),
+		})
+	// P(1/2, x) = erf(√x).
+	WantFunc(t, "P(0.5, %v)",
+		func(x float64) float64 { return GammaInc(0.5, x) },
+		map[float64]float64{
+			0.25: math.Erf(0.5),
+			1:    math.Erf(1),
+			4:    math.Erf(2),
+		})
+	// P(n, x) = 1 - e⁻ˣ Σ_{k<n} xᵏ/k! for integer n.
+	WantFunc(t, "P(%v, 4)",
+		func(a float64) float64 { return GammaInc(a, 4) },
+		map[float64]float64{
+			2: 1 - 5*math.Exp(-4),
+			3: 1 - 13*math.Exp(-4),
+			5: 1 - (1+4+8+32.0/3+32.0/3)*math.Exp(-4),
+		})
+}
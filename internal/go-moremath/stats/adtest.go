@@ -0,0 +1,176 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// An AndersonDarlingTestResult is the result of a two-sample
+// Anderson-Darling test.
+type AndersonDarlingTestResult struct {
+	// N1 and N2 are the sizes of the input samples.
+	N1, N2 int
+
+	// A2 is the Anderson-Darling statistic A²akN, adjusted for
+	// ties.
+	A2 float64
+
+	// T is A2 standardized by its mean and standard deviation
+	// under the null hypothesis.
+	T float64
+
+	// P is the p-value of the test against the null hypothesis
+	// that both samples come from the same distribution. It is
+	// interpolated from a table of critical values and clamped to
+	// the range of the table, [0.001, 0.25].
+	P float64
+}
+
+// AndersonDarlingTest performs a two-sample Anderson-Darling test [1]
+// of the null hypothesis that x1 and x2 come from the same
+// distribution against the alternative hypothesis that their
+// distributions differ in any way.
+//
+// Like the Kolmogorov-Smirnov test, this detects differences in the
+// spread or shape of the distributions as well as their location,
+// but it gives more weight to the tails of the distributions.
+//
+// The statistic accounts for ties using midranks. The p-value comes
+// from a quadratic fit of the logarithm of the significance level to
+// the asymptotic critical values of T given by Scholz and Stephens,
+// and so is accurate for samples of more than a few values.
+//
+// This can fail with ErrSampleSize if either sample is empty or the
+// samples have fewer than 4 values in all, or ErrSamplesEqual if all
+// sample values are equal.
+//
+// [1] Scholz, F. W. and Stephens, M. A. (1987). "K-Sample
+// Anderson-Darling Tests". Journal of the American Statistical
+// Association 82 (399): 918-924.
+func AndersonDarlingTest(x1, x2 []float64) (*AndersonDarlingTestResult, error) {
+	n1, n2 := len(x1), len(x2)
+	N := n1 + n2
+	if n1 == 0 || n2 == 0 || N < 4 {
+		return nil, ErrSampleSize
+	}
+	x1 = append([]float64(nil), x1...)
+	x2 = append([]float64(nil), x2...)
+	sort.Float64s(x1)
+	sort.Float64s(x2)
+
+	// Walk the distinct values z_j of the merged sample. l is the
+	// number of values equal to z_j, B is the number of values
+	// <= z_j, and M[i] is the number of values of sample i <= z_j.
+	samples := [2][]float64{x1, x2}
+	ns := [2]float64{float64(n1), float64(n2)}
+	fN := float64(N)
+	var sum [2]float64
+	var idx [2]int
+	B := 0.0
+	distinct := 0
+	for idx[0] < n1 || idx[1] < n2 {
+		var z float64
+		if idx[1] == n2 || idx[0] < n1 && x1[idx[0]] <= x2[idx[1]] {
+			z = x1[idx[0]]
+		} else {
+			z = x2[idx[1]]
+		}
+		distinct++
+		var f [2]float64
+		for i, x := range samples {
+			for idx[i] < len(x) && x[idx[i]] == z {
+				idx[i]++
+				f[i]++
+			}
+		}
+		l := f[0] + f[1]
+		B += l
+		Ba := B - l/2
+		denom := Ba*(fN-Ba) - fN*l/4
+		if denom <= 0 {
+			// Only possible if every value equals z.
+			continue
+		}
+		for i := range samples {
+			Ma := float64(idx[i]) - f[i]/2
+			d := fN*Ma - ns[i]*Ba
+			sum[i] += l / fN * d * d / denom
+		}
+	}
+	if distinct == 1 {
+		return nil, ErrSamplesEqual
+	}
+	A2 := (fN - 1) / fN * (sum[0]/ns[0] + sum[1]/ns[1])
+
+	// Standardize A2 using its variance under the null
+	// hypothesis (Scholz and Stephens, equation 4, with k = 2).
+	const k = 2
+	H := 1/ns[0] + 1/ns[1]
+	h, g := 0.0, 0.0
+	for i := 1; i < N; i++ {
+		h += 1 / float64(i)
+	}
+	// g = Σ_{i=1}^{N-2} Σ_{j=i+1}^{N-1} 1/((N-i) j).
+	tail := 0.0 // Σ_{j=i+1}^{N-1} 1/j
+	for i := N - 2; i >= 1; i-- {
+		tail += 1 / float64(i+1)
+		g += tail / float64(N-i)
+	}
+	a := (4*g-6)*(k-1) + (10-6*g)*H
+	b := (2*g-4)*k*k + 8*h*k + (2*g-14*h-4)*H - 8*h + 4*g - 6
+	c := (6*h+2*g-2)*k*k + (4*h-4*g+6)*k + (2*h-6)*H + 4*h
+	d := (2*h+6)*k*k - 4*h*k
+	σ2 := (a*fN*fN*fN + b*fN*fN + c*fN + d) / ((fN - 1) * (fN - 2) * (fN - 3))
+	T := (A2 - (k - 1)) / math.Sqrt(σ2)
+
+	return &AndersonDarlingTestResult{N1: n1, N2: n2, A2: A2, T: T, P: adPValue(T)}, nil
+}
+
+// adSigLevels and adCritical give the significance levels and the
+// corresponding asymptotic critical values of T for the two-sample
+// Anderson-Darling test (Scholz and Stephens, table 1, m = 1).
+var (
+	adSigLevels = []float64{0.25, 0.1, 0.05, 0.025, 0.01, 0.005, 0.001}
+	adCritical  = []float64{0.325, 1.226, 1.961, 2.718, 3.752, 4.592, 6.546}
+)
+
+// adPValue returns the p-value of the standardized Anderson-Darling
+// statistic T by fitting a quadratic in T to the logarithm of the
+// significance levels of the critical values.
+func adPValue(T float64) float64 {
+	// Least-squares fit of log(sig) = c0 + c1 T + c2 T².
+	var A [3][4]float64
+	for i, crit := range adCritical {
+		row := [3]float64{1, crit, crit * crit}
+		y := math.Log(adSigLevels[i])
+		for r := 0; r < 3; r++ {
+			for c := 0; c < 3; c++ {
+				A[r][c] += row[r] * row[c]
+			}
+			A[r][3] += row[r] * y
+		}
+	}
+	// Solve the normal equations by Gaussian elimination.
+	for p := 0; p < 3; p++ {
+		for r := p + 1; r < 3; r++ {
+			f := A[r][p] / A[p][p]
+			for c := p; c < 4; c++ {
+				A[r][c] -= f * A[p][c]
+			}
+		}
+	}
+	var coef [3]float64
+	for r := 2; r >= 0; r-- {
+		s := A[r][3]
+		for c := r + 1; c < 3; c++ {
+			s -= A[r][c] * coef[c]
+		}
+		coef[r] = s / A[r][r]
+	}
+	p := math.Exp(coef[0] + coef[1]*T + coef[2]*T*T)
+	return math.Max(adSigLevels[len(adSigLevels)-1], math.Min(adSigLevels[0], p))
+}
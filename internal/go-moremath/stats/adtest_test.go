@@ -0,0 +1,42 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestAndersonDarlingTest(t *testing.T) {
+	check := func(x1, x2 []float64, A2, T, P float64) {
+		want := &AndersonDarlingTestResult{N1: len(x1), N2: len(x2), A2: A2, T: T, P: P}
+		got, err := AndersonDarlingTest(x1, x2)
+		if err != nil {
+			t.Errorf("AndersonDarlingTest(%v, %v): %v", x1, x2, err)
+			return
+		}
+		if want.N1 != got.N1 || want.N2 != got.N2 || !aeq(want.A2, got.A2) || !aeq(want.T, got.T) || !aeq(want.P, got.P) {
+			t.Errorf("want %+v, got %+v", want, got)
+		}
+		// The test is symmetric in its samples.
+		got, _ = AndersonDarlingTest(x2, x1)
+		if !aeq(want.A2, got.A2) || !aeq(want.P, got.P) {
+			t.Errorf("swapped: want %+v, got %+v", want, got)
+		}
+	}
+
+	check([]float64{1, 2, 3, 4, 5, 6, 7, 8}, []float64{5, 6, 7, 8, 9, 10, 11, 12},
+		3.7019186436324256, 3.966211508746168, 0.00830964090669154)
+	// Ties.
+	check([]float64{1, 2, 2, 3, 3, 3, 4, 5}, []float64{2, 3, 3, 4, 4, 5, 6, 6, 7},
+		2.4196650603921674, 2.0706686239624927, 0.04561831515686118)
+	// Interleaved samples; the p-value is clamped.
+	check([]float64{10, 11, 12, 13, 14}, []float64{10.5, 11.5, 12.5, 13.5, 14.5},
+		0.31404168804004384, -1.0754112607721593, 0.25)
+
+	if r, err := AndersonDarlingTest([]float64{1, 1}, []float64{1, 1}); err != ErrSamplesEqual {
+		t.Errorf("want ErrSamplesEqual, got %+v, %+v", r, err)
+	}
+	if r, err := AndersonDarlingTest([]float64{1}, []float64{2, 3}); err != ErrSampleSize {
+		t.Errorf("want ErrSampleSize, got %+v, %+v", r, err)
+	}
+}
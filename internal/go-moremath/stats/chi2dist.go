@@ -0,0 +1,46 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+
+	"rsc.io/benchstat/internal/go-moremath/mathx"
+)
+
+// A ChiSquaredDist is a χ² distribution with K degrees of freedom:
+// the distribution of the sum of the squares of K independent
+// standard normal variables.
+type ChiSquaredDist struct {
+	K float64
+}
+
+func (c ChiSquaredDist) PDF(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x == 0 {
+		switch {
+		case c.K < 2:
+			return math.Inf(1)
+		case c.K == 2:
+			return 0.5
+		}
+		return 0
+	}
+	k2 := c.K / 2
+	return math.Exp((k2-1)*math.Log(x) - x/2 - k2*math.Ln2 - lgamma(k2))
+}
+
+func (c ChiSquaredDist) CDF(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return mathx.GammaInc(c.K/2, x/2)
+}
+
+func (c ChiSquaredDist) Bounds() (float64, float64) {
+	return 0, c.K + 4*math.Sqrt(2*c.K)
+}
@@ -0,0 +1,39 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChiSquared(t *testing.T) {
+	testFunc(t, "PDF(%v|k=2)", ChiSquaredDist{2}.PDF, map[float64]float64{
+		0: 0.5,
+		1: 0.5 * math.Exp(-0.5),
+		4: 0.5 * math.Exp(-2),
+	})
+	testFunc(t, "PDF(%v|k=4)", ChiSquaredDist{4}.PDF, map[float64]float64{
+		0: 0,
+		2: 0.5 * math.Exp(-1),
+		6: 1.5 * math.Exp(-3),
+	})
+	testFunc(t, "CDF(%v|k=2)", ChiSquaredDist{2}.CDF, map[float64]float64{
+		-1: 0,
+		0:  0,
+		1:  1 - math.Exp(-0.5),
+		4:  1 - math.Exp(-2),
+	})
+	// Critical values at the 95th percentile.
+	testFunc(t, "CDF(%v|k=1)", ChiSquaredDist{1}.CDF, map[float64]float64{
+		3.841458820694124: 0.95,
+	})
+	testFunc(t, "CDF(%v|k=3)", ChiSquaredDist{3}.CDF, map[float64]float64{
+		7.814727903251178: 0.95,
+	})
+	testFunc(t, "CDF(%v|k=10)", ChiSquaredDist{10}.CDF, map[float64]float64{
+		18.307038053275146: 0.95,
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "math"
+
+// CohensD returns Cohen's d, the difference between the means of x1
+// and x2 in units of their pooled standard deviation:
+//
+//	d = (mean(x1) - mean(x2)) / s_pooled
+//
+// Unlike a p-value, d does not grow with the sample size, so it
+// distinguishes a large change from a small change measured
+// precisely. It returns NaN if the samples have fewer than 3 values
+// in all, and ±Inf if they have no variance.
+func CohensD(x1, x2 TTestSample) float64 {
+	n1, n2 := x1.Weight(), x2.Weight()
+	if n1+n2 <= 2 {
+		return nan
+	}
+	v := ((n1-1)*x1.Variance() + (n2-1)*x2.Variance()) / (n1 + n2 - 2)
+	return (x1.Mean() - x2.Mean()) / math.Sqrt(v)
+}
+
+// HedgesG returns Hedges' g, which is Cohen's d corrected for its
+// upward bias in small samples.
+//
+// [1] Hedges, L. V. (1981). "Distribution theory for Glass's
+// estimator of effect size and related estimators". Journal of
+// Educational Statistics 6 (2): 107-128.
+func HedgesG(x1, x2 TTestSample) float64 {
+	n := x1.Weight() + x2.Weight()
+	return CohensD(x1, x2) * (1 - 3/(4*n-9))
+}
+
+// CommonLanguageEffect returns the common-language effect size of x1
+// and x2: the probability that a value drawn at random from x1 is
+// greater than one drawn from x2, counting ties as one half. This is
+// the Mann-Whitney U statistic divided by N1*N2. It returns NaN if
+// either sample is empty.
+func CommonLanguageEffect(x1, x2 []float64) float64 {
+	if len(x1) == 0 || len(x2) == 0 {
+		return nan
+	}
+	x1 = append([]float64(nil), x1...)
+	x2 = append([]float64(nil), x2...)
+	return uStatistic(x1, x2) / float64(len(x1)*len(x2))
+}
+
+// CliffsDelta returns Cliff's δ, the probability that a value drawn
+// at random from x1 is greater than one drawn from x2, minus the
+// probability that it is less. It is 2*CommonLanguageEffect(x1, x2) - 1,
+// in the range [-1, 1].
+//
+// [1] Cliff, N. (1993). "Dominance statistics: Ordinal analyses to
+// answer ordinal questions". Psychological Bulletin 114 (3): 494-509.
+func CliffsDelta(x1, x2 []float64) float64 {
+	return 2*CommonLanguageEffect(x1, x2) - 1
+}
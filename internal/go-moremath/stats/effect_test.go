@@ -0,0 +1,32 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestEffectSizes(t *testing.T) {
+	a := []float64{1, 2, 3, 4, 5}
+	b := []float64{3, 4, 5, 6, 7, 8}
+	s1, s2 := Sample{Xs: a}, Sample{Xs: b}
+	check := func(name string, want, got float64) {
+		t.Helper()
+		if !aeq(want, got) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	check("CohensD", -1.4301938838683885, CohensD(s1, s2))
+	check("CohensD swapped", 1.4301938838683885, CohensD(s2, s1))
+	check("HedgesG", -1.3076058366796695, HedgesG(s1, s2))
+	check("CommonLanguageEffect", 0.15, CommonLanguageEffect(a, b))
+	check("CommonLanguageEffect swapped", 0.85, CommonLanguageEffect(b, a))
+	check("CliffsDelta", -0.7, CliffsDelta(a, b))
+	check("CliffsDelta equal", 0, CliffsDelta(a, a))
+	// The inputs must not be reordered.
+	c := []float64{3, 1, 2}
+	CommonLanguageEffect(c, a)
+	if c[0] != 3 || c[1] != 1 || c[2] != 2 {
+		t.Errorf("CommonLanguageEffect modified its input: %v", c)
+	}
+}
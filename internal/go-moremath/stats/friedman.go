@@ -0,0 +1,146 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "math"
+
+// A FriedmanTestResult is the result of a Friedman test.
+type FriedmanTestResult struct {
+	// Blocks is the number of blocks and Treatments is the
+	// number of treatments, that is, values in each block.
+	Blocks, Treatments int
+
+	// Q is the Friedman statistic, corrected for ties.
+	Q float64
+
+	// DoF is the degrees of freedom of the χ² distribution
+	// approximating the distribution of Q: one less than the
+	// number of treatments.
+	DoF int
+
+	// P is the p-value of the Friedman test for the null
+	// hypothesis that the treatments have the same effect.
+	P float64
+}
+
+// FriedmanTest performs a Friedman test on a randomized complete
+// block design. Each element of blocks holds one value for each
+// treatment, in the same order; for example, each block could be one
+// run of a set of benchmark configurations. This is a test of the
+// null hypothesis that the treatments have the same effect, against
+// the alternative that at least one of them tends to give larger
+// values than another. It ranks the values within each block, so,
+// like the Wilcoxon signed-rank test it generalizes, it discounts
+// differences between the blocks.
+//
+// Tied values within a block receive their mean rank and Q is
+// corrected for them. The p-value comes from the χ² approximation to
+// the distribution of Q.
+//
+// To find which treatments differ, follow a significant result with
+// ConoverFriedmanTest.
+//
+// Returns ErrSampleSize if there are fewer than two blocks or two
+// treatments, ErrMismatchedSamples if the blocks have different
+// lengths, or ErrSamplesEqual if all values within each block are
+// equal.
+func FriedmanTest(blocks [][]float64) (*FriedmanTestResult, error) {
+	sums, A, err := friedmanRanks(blocks)
+	if err != nil {
+		return nil, err
+	}
+	n, k := float64(len(blocks)), float64(len(sums))
+	// Q is the sum of squared deviations of the rank sums from
+	// their expected value, relative to the variance of the
+	// ranks. Without ties, this is
+	//
+	//	12/(nk(k+1)) Σ Rⱼ² - 3n(k+1).
+	C := n * k * (k + 1) * (k + 1) / 4
+	ss := 0.0
+	for _, R := range sums {
+		ss += R * R
+	}
+	Q := (k - 1) * (ss - n*C) / (A - C)
+	dof := len(sums) - 1
+	p := 1 - ChiSquaredDist{float64(dof)}.CDF(Q)
+	return &FriedmanTestResult{Blocks: len(blocks), Treatments: len(sums), Q: Q, DoF: dof, P: p}, nil
+}
+
+// ConoverFriedmanTest performs Conover's test [1] for pairwise
+// differences between treatments following a Friedman test. It
+// returns a matrix of two-sided p-values P, where P[i][j] is the
+// p-value for the null hypothesis that treatments i and j have the
+// same effect. It compares the within-block rank sums using a
+// t-distribution, which makes it more powerful than comparisons
+// based on the normal approximation.
+//
+// The p-values are not adjusted for multiple comparisons. Callers
+// should adjust them, e.g., by Holm's or Bonferroni's method.
+//
+// Returns the same errors as FriedmanTest.
+//
+// [1] Conover, W. J. (1999). Practical Nonparametric Statistics,
+// 3rd ed., pp. 371-372. Wiley.
+func ConoverFriedmanTest(blocks [][]float64) ([][]float64, error) {
+	sums, A, err := friedmanRanks(blocks)
+	if err != nil {
+		return nil, err
+	}
+	n, k := float64(len(blocks)), float64(len(sums))
+	ss := 0.0
+	for _, R := range sums {
+		ss += R * R
+	}
+	dof := (n - 1) * (k - 1)
+	se := math.Sqrt(2 * (n*A - ss) / dof)
+	dist := TDist{dof}
+	P := make([][]float64, len(sums))
+	for i := range P {
+		P[i] = make([]float64, len(sums))
+		for j := range P[i] {
+			d := math.Abs(sums[i] - sums[j])
+			switch {
+			case d == 0:
+				P[i][j] = 1
+			case se == 0:
+				// The blocks rank the treatments
+				// identically.
+				P[i][j] = 0
+			default:
+				P[i][j] = 2 * (1 - dist.CDF(d/se))
+			}
+		}
+	}
+	return P, nil
+}
+
+// friedmanRanks ranks the values within each block and returns the
+// sum of the ranks of each treatment and the sum of the squares of
+// all of the ranks.
+func friedmanRanks(blocks [][]float64) (sums []float64, A float64, err error) {
+	if len(blocks) < 2 || len(blocks[0]) < 2 {
+		return nil, 0, ErrSampleSize
+	}
+	k := len(blocks[0])
+	sums = make([]float64, k)
+	allTied := true
+	for _, block := range blocks {
+		if len(block) != k {
+			return nil, 0, ErrMismatchedSamples
+		}
+		ranks, ties := midranks(block)
+		if ties != float64(k*k*k-k) {
+			allTied = false
+		}
+		for j, r := range ranks {
+			sums[j] += r
+			A += r * r
+		}
+	}
+	if allTied {
+		return nil, 0, ErrSamplesEqual
+	}
+	return sums, A, nil
+}
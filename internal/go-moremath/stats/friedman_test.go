@@ -0,0 +1,57 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestFriedmanTest(t *testing.T) {
+	// RoundingTimes example from R's friedman.test documentation
+	// (Hollander & Wolfe, 1973): times to round first base by
+	// three methods for 22 players.
+	blocks := [][]float64{
+		{5.40, 5.50, 5.55}, {5.85, 5.70, 5.75}, {5.20, 5.60, 5.50},
+		{5.55, 5.50, 5.40}, {5.90, 5.85, 5.70}, {5.45, 5.55, 5.60},
+		{5.40, 5.40, 5.35}, {5.45, 5.50, 5.35}, {5.25, 5.15, 5.00},
+		{5.85, 5.80, 5.70}, {5.25, 5.20, 5.10}, {5.65, 5.55, 5.45},
+		{5.60, 5.35, 5.45}, {5.05, 5.00, 4.95}, {5.50, 5.50, 5.40},
+		{5.45, 5.55, 5.50}, {5.55, 5.55, 5.35}, {5.45, 5.50, 5.55},
+		{5.50, 5.45, 5.25}, {5.65, 5.60, 5.40}, {5.70, 5.65, 5.55},
+		{6.30, 6.30, 6.25},
+	}
+	r, err := FriedmanTest(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Blocks != 22 || r.Treatments != 3 || r.DoF != 2 || !aeq(r.Q, 11.142857142857142) || !aeq(r.P, 0.003805040775511363) {
+		t.Errorf("FriedmanTest = %+v, want Q=11.142857 P=0.003805", r)
+	}
+
+	want := [][]float64{
+		{1, 0.301209021439371, 0.0006914534760853464},
+		{0.301209021439371, 1, 0.012282857347899023},
+		{0.0006914534760853464, 0.012282857347899023, 1},
+	}
+	P, err := ConoverFriedmanTest(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if !aeq(want[i][j], P[i][j]) {
+				t.Errorf("ConoverFriedmanTest[%d][%d] = %v, want %v", i, j, P[i][j], want[i][j])
+			}
+		}
+	}
+
+	if _, err := FriedmanTest([][]float64{{1, 2}}); err != ErrSampleSize {
+		t.Errorf("one block: want ErrSampleSize, got %v", err)
+	}
+	if _, err := FriedmanTest([][]float64{{1, 2}, {1, 2, 3}}); err != ErrMismatchedSamples {
+		t.Errorf("ragged blocks: want ErrMismatchedSamples, got %v", err)
+	}
+	if _, err := FriedmanTest([][]float64{{1, 1}, {2, 2}}); err != ErrSamplesEqual {
+		t.Errorf("tied blocks: want ErrSamplesEqual, got %v", err)
+	}
+}
@@ -7,6 +7,7 @@ package stats
 import (
 	"fmt"
 	"math"
+	"math/rand"
 )
 
 // A KDE is a distribution that estimates the underlying distribution
@@ -266,6 +267,118 @@ func (kde *KDE) CDF(x float64) float64 {
 	}
 }
 
+// Rand draws a random value from the KDE. It picks a sample value
+// (in proportion to its weight) and adds noise drawn from the kernel.
+// The source of randomness r may be nil, in which case it uses the
+// default global source.
+func (kde *KDE) Rand(r *rand.Rand) float64 {
+	_, bc := kde.prepare()
+	float := rand.Float64
+	if r != nil {
+		float = r.Float64
+	}
+
+	// Pick a sample value.
+	xs := kde.Sample.Xs
+	var x float64
+	if kde.Sample.Weights == nil {
+		x = xs[int(float()*float64(len(xs)))%len(xs)]
+	} else {
+		target := float() * kde.Sample.Weight()
+		x = xs[len(xs)-1]
+		for i, w := range kde.Sample.Weights {
+			target -= w
+			if target < 0 {
+				x = xs[i]
+				break
+			}
+		}
+	}
+
+	// Add kernel noise.
+	switch kde.Kernel {
+	case EpanechnikovKernel:
+		// Devroye, L. (1986) Non-Uniform Random Variate
+		// Generation, p. 236.
+		u1, u2, u3 := 2*float()-1, 2*float()-1, 2*float()-1
+		if math.Abs(u3) >= math.Abs(u2) && math.Abs(u3) >= math.Abs(u1) {
+			x += u2 * kde.Bandwidth
+		} else {
+			x += u3 * kde.Bandwidth
+		}
+	case GaussianKernel:
+		x = NormalDist{x, kde.Bandwidth}.Rand(r)
+	}
+
+	// Reflect values outside the boundaries back in, matching
+	// BoundaryReflect.
+	if bc {
+		for x < kde.BoundaryMin || x > kde.BoundaryMax {
+			if x < kde.BoundaryMin {
+				x = 2*kde.BoundaryMin - x
+			} else {
+				x = 2*kde.BoundaryMax - x
+			}
+		}
+	}
+	return x
+}
+
+// Modes returns the locations of the local maxima of the PDF of
+// kde, in increasing order. It ignores maxima whose prominence is less
+// than minRatio times the highest density, which are usually the
+// result of noise or a single stray sample. The prominence of a maximum
+// is its height above the lowest point on the way to a higher maximum
+// (or the edge of the distribution), so a bump on the shoulder of a
+// larger mode has little prominence even if it is high.
+//
+// The maxima are located by evaluating the PDF at evenly spaced
+// points across Bounds, so they are accurate to about 0.2% of the
+// width of the bounds.
+func (kde *KDE) Modes(minRatio float64) []float64 {
+	const steps = 512
+	low, high := kde.Bounds()
+	xs := make([]float64, steps+1)
+	ys := make([]float64, steps+1)
+	maxY := 0.0
+	for i := range xs {
+		xs[i] = low + (high-low)*float64(i)/steps
+		ys[i] = kde.PDF(xs[i])
+		maxY = math.Max(maxY, ys[i])
+	}
+
+	// valley returns the lowest point between i and the first
+	// point higher than ys[i] in direction dir.
+	valley := func(i, dir int) float64 {
+		min := ys[i]
+		for j := i + dir; j >= 0 && j <= steps && ys[j] <= ys[i]; j += dir {
+			min = math.Min(min, ys[j])
+		}
+		return min
+	}
+
+	var modes []float64
+	for i := 1; i < steps; i++ {
+		if !(ys[i-1] < ys[i] && ys[i] >= ys[i+1]) {
+			continue
+		}
+		// Take the middle of a plateau.
+		j := i
+		for j < steps && ys[j+1] == ys[i] {
+			j++
+		}
+		if j < steps && ys[j+1] > ys[i] {
+			// Not a maximum after all.
+			continue
+		}
+		prominence := ys[i] - math.Max(valley(i, -1), valley(j, 1))
+		if prominence >= minRatio*maxY {
+			modes = append(modes, (xs[i]+xs[j])/2)
+		}
+	}
+	return modes
+}
+
 func (kde *KDE) Bounds() (low float64, high float64) {
 	_, bc := kde.prepare()
 
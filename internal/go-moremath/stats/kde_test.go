@@ -6,6 +6,7 @@ package stats
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 )
 
@@ -87,3 +88,58 @@ func TestKDETwoSamples(t *testing.T) {
 		3: 0.670672373,
 		4: 0.812327630})
 }
+
+func TestKDERand(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, kernel := range []KDEKernel{EpanechnikovKernel, GaussianKernel} {
+		kde := KDE{
+			Sample:      Sample{Xs: []float64{1, 3}},
+			Kernel:      kernel,
+			Bandwidth:   2,
+			BoundaryMin: 0,
+			BoundaryMax: inf,
+		}
+		xs := make([]float64, 10000)
+		for i := range xs {
+			xs[i] = kde.Rand(r)
+			if xs[i] < 0 {
+				t.Fatalf("%v: Rand() = %v, want >= 0", kernel, xs[i])
+			}
+		}
+		// Compare the empirical CDF with the KDE's CDF.
+		sample := Sample{Xs: xs}
+		sample.Sort()
+		for _, x := range []float64{0.5, 1, 2, 3, 4} {
+			want := kde.CDF(x)
+			got := sample.Percentile(want)
+			if diff := got - x; diff < -0.1 || diff > 0.1 {
+				t.Errorf("%v: quantile %v of Rand samples is %v, want %v", kernel, want, got, x)
+			}
+		}
+	}
+}
+
+func TestKDEModes(t *testing.T) {
+	check := func(xs []float64, minRatio float64, want []float64) {
+		t.Helper()
+		kde := KDE{Sample: Sample{Xs: xs}, Kernel: GaussianKernel, Bandwidth: 1}
+		got := kde.Modes(minRatio)
+		if len(got) != len(want) {
+			t.Errorf("Modes(%v) of %v = %v, want %v", minRatio, xs, got, want)
+			return
+		}
+		for i := range got {
+			if diff := got[i] - want[i]; diff < -0.1 || diff > 0.1 {
+				t.Errorf("Modes(%v) of %v = %v, want %v", minRatio, xs, got, want)
+				return
+			}
+		}
+	}
+	check([]float64{10}, 0, []float64{10})
+	check([]float64{9, 10, 11}, 0, []float64{10})
+	check([]float64{9, 10, 11, 19, 20, 21}, 0, []float64{10, 20})
+	// The lone sample at 40 is a mode only if it is not ignored.
+	two := []float64{9, 10, 10, 11, 19, 20, 20, 21, 40}
+	check(two, 0, []float64{10, 20, 40})
+	check(two, 0.5, []float64{10, 20})
+}
@@ -0,0 +1,167 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// A KolmogorovSmirnovTestResult is the result of a two-sample
+// Kolmogorov-Smirnov test.
+type KolmogorovSmirnovTestResult struct {
+	// N1 and N2 are the sizes of the input samples.
+	N1, N2 int
+
+	// D is the Kolmogorov-Smirnov statistic: the largest
+	// absolute difference between the empirical CDFs of the two
+	// samples. It is in the range [0, 1].
+	D float64
+
+	// P is the p-value of the test against the null hypothesis
+	// that both samples come from the same distribution.
+	P float64
+}
+
+// KolmogorovSmirnovExactLimit gives the largest value of N1*N2 for
+// which the exact distribution of D will be used for the
+// Kolmogorov-Smirnov test.
+var KolmogorovSmirnovExactLimit = 10000
+
+// KolmogorovSmirnovTest performs a two-sample Kolmogorov-Smirnov test
+// [1] of the null hypothesis that x1 and x2 come from the same
+// distribution against the alternative hypothesis that their
+// distributions differ in any way.
+//
+// Unlike location tests such as the t-test and the Mann-Whitney
+// U-test, this detects differences in the spread or shape of the
+// distributions, such as a heavier tail, even if their locations are
+// the same. The Anderson-Darling test is more sensitive to
+// differences in the tails.
+//
+// If there are no ties and N1*N2 <= KolmogorovSmirnovExactLimit, this
+// computes the exact p-value. Otherwise, it uses the asymptotic
+// Kolmogorov distribution with Stephens' correction for small
+// samples, which is conservative in the presence of ties.
+//
+// This can fail with ErrSampleSize if either sample is empty or
+// ErrSamplesEqual if all sample values are equal.
+//
+// [1] Smirnov, N. V. (1939). "Estimate of deviation between empirical
+// distribution functions in two independent samples". Bulletin of
+// Moscow University 2 (2): 3-16.
+func KolmogorovSmirnovTest(x1, x2 []float64) (*KolmogorovSmirnovTestResult, error) {
+	n1, n2 := len(x1), len(x2)
+	if n1 == 0 || n2 == 0 {
+		return nil, ErrSampleSize
+	}
+	x1 = append([]float64(nil), x1...)
+	x2 = append([]float64(nil), x2...)
+	sort.Float64s(x1)
+	sort.Float64s(x2)
+	if x1[0] == x1[n1-1] && x2[0] == x2[n2-1] && x1[0] == x2[0] {
+		return nil, ErrSamplesEqual
+	}
+
+	// Walk the merged samples, computing n1*n2 times the
+	// difference of the empirical CDFs after each distinct value.
+	// Working in integers keeps the exact computation exact.
+	Dint, hasTies := 0, false
+	i, j := 0, 0
+	for i < n1 || j < n2 {
+		var v float64
+		if j == n2 || i < n1 && x1[i] <= x2[j] {
+			v = x1[i]
+		} else {
+			v = x2[j]
+		}
+		i0, j0 := i, j
+		for i < n1 && x1[i] == v {
+			i++
+		}
+		for j < n2 && x2[j] == v {
+			j++
+		}
+		if i-i0+j-j0 > 1 {
+			hasTies = true
+		}
+		if d := absint(i*n2 - j*n1); d > Dint {
+			Dint = d
+		}
+	}
+	D := float64(Dint) / float64(n1*n2)
+
+	var p float64
+	if !hasTies && n1*n2 <= KolmogorovSmirnovExactLimit {
+		p = 1 - ksInside(n1, n2, Dint)
+	} else {
+		ne := float64(n1*n2) / float64(n1+n2)
+		λ := (math.Sqrt(ne) + 0.12 + 0.11/math.Sqrt(ne)) * D
+		p = kolmogorovQ(λ)
+	}
+	if p < 0 {
+		p = 0
+	}
+	return &KolmogorovSmirnovTestResult{N1: n1, N2: n2, D: D, P: p}, nil
+}
+
+// ksInside returns the probability under the null hypothesis that
+// n1*n2 times the difference of the empirical CDFs stays strictly
+// within ±Dint at every point.
+//
+// This counts the lattice paths from (0, 0) to (n1, n2) that stay
+// within the band. q[j] is the number of paths to (i, j) divided by
+// the number of unrestricted paths to (i, j), which keeps the values
+// in [0, 1].
+func ksInside(n1, n2, Dint int) float64 {
+	inside := func(i, j int) bool {
+		return absint(i*n2-j*n1) < Dint
+	}
+	q := make([]float64, n2+1)
+	for i := 0; i <= n1; i++ {
+		for j := 0; j <= n2; j++ {
+			switch {
+			case !inside(i, j):
+				q[j] = 0
+			case i == 0 && j == 0:
+				q[j] = 1
+			case i == 0:
+				q[j] = q[j-1]
+			case j == 0:
+				// q[j] is already the value at (i-1, 0).
+			default:
+				q[j] = (q[j]*float64(i) + q[j-1]*float64(j)) / float64(i+j)
+			}
+		}
+	}
+	return q[n2]
+}
+
+// kolmogorovQ returns the complementary CDF of the Kolmogorov
+// distribution, Q(λ) = 2 Σ_{k=1}^∞ (-1)^(k-1) exp(-2k²λ²).
+func kolmogorovQ(λ float64) float64 {
+	if λ < 0.2 {
+		// The series converges slowly, but Q is 1 to
+		// double precision.
+		return 1
+	}
+	sum, sign := 0.0, 1.0
+	for k := 1.0; k <= 100; k++ {
+		term := sign * math.Exp(-2*k*k*λ*λ)
+		sum += term
+		if math.Abs(term) < 1e-16*math.Abs(sum) {
+			break
+		}
+		sign = -sign
+	}
+	return math.Min(1, 2*sum)
+}
+
+func absint(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
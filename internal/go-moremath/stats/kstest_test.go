@@ -0,0 +1,45 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestKolmogorovSmirnovTest(t *testing.T) {
+	check := func(x1, x2 []float64, D, P float64) {
+		want := &KolmogorovSmirnovTestResult{N1: len(x1), N2: len(x2), D: D, P: P}
+		got, err := KolmogorovSmirnovTest(x1, x2)
+		if err != nil {
+			t.Errorf("KolmogorovSmirnovTest(%v, %v): %v", x1, x2, err)
+			return
+		}
+		if want.N1 != got.N1 || want.N2 != got.N2 || !aeq(want.D, got.D) || !aeq(want.P, got.P) {
+			t.Errorf("want %+v, got %+v", want, got)
+		}
+	}
+
+	// Exact p-values, checked by enumerating every assignment of
+	// the pooled values to the two samples.
+	check([]float64{1, 2, 3, 5}, []float64{12, 11, 13, 15}, 1, 0.02857142857142857)
+	check([]float64{2, 1, 3, 5}, []float64{0, 4, 6, 7}, 0.5, 0.7714285714285715)
+	check([]float64{1, 2, 3, 4, 5, 6}, []float64{3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5}, 4.0/7, 0.14685314685314685)
+
+	// Ties use the asymptotic distribution.
+	t1 := make([]float64, 30)
+	t2 := make([]float64, 40)
+	for i := range t1 {
+		t1[i] = float64(i % 7)
+	}
+	for i := range t2 {
+		t2[i] = float64(i % 9)
+	}
+	check(t1, t2, 0.2, 0.4541588208036166)
+
+	if r, err := KolmogorovSmirnovTest([]float64{1, 1}, []float64{1}); err != ErrSamplesEqual {
+		t.Errorf("want ErrSamplesEqual, got %+v, %+v", r, err)
+	}
+	if r, err := KolmogorovSmirnovTest(nil, []float64{1}); err != ErrSampleSize {
+		t.Errorf("want ErrSampleSize, got %+v, %+v", r, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// A KruskalWallisTestResult is the result of a Kruskal-Wallis test.
+type KruskalWallisTestResult struct {
+	// N is the size of each input sample.
+	N []int
+
+	// H is the Kruskal-Wallis statistic, corrected for ties.
+	H float64
+
+	// DoF is the degrees of freedom of the χ² distribution
+	// approximating the distribution of H: one less than the
+	// number of samples.
+	DoF int
+
+	// P is the p-value of the Kruskal-Wallis test for the null
+	// hypothesis that all of the samples are drawn from the same
+	// distribution.
+	P float64
+}
+
+// KruskalWallisTest performs a Kruskal-Wallis one-way analysis of
+// variance by ranks on the given samples. This is a test of the null
+// hypothesis that all of the samples are drawn from the same
+// distribution, against the alternative that at least one of them
+// tends to have larger values than another. It generalizes the
+// Mann-Whitney U-test to more than two samples and, like it, makes no
+// assumption about the shape of the distribution.
+//
+// Tied values receive their mean rank and H is corrected for them.
+// The p-value comes from the χ² approximation to the distribution of
+// H, which is reasonable if each sample has at least 5 values.
+//
+// To find which samples differ, follow a significant result with
+// DunnTest.
+//
+// Returns ErrSampleSize if there are fewer than two samples or any
+// sample is empty, or ErrSamplesEqual if all values are equal.
+func KruskalWallisTest(samples ...[]float64) (*KruskalWallisTestResult, error) {
+	meanRanks, variance, err := kwRanks(samples)
+	if err != nil {
+		return nil, err
+	}
+	N := 0
+	ns := make([]int, len(samples))
+	for i, s := range samples {
+		ns[i] = len(s)
+		N += len(s)
+	}
+	// H is the weighted sum of squared deviations of the mean
+	// ranks from the overall mean rank, relative to the variance
+	// of the ranks. Without ties, this is
+	//
+	//	12/(N(N+1)) Σ nᵢ(R̄ᵢ - (N+1)/2)².
+	H := 0.0
+	for i, r := range meanRanks {
+		d := r - float64(N+1)/2
+		H += float64(ns[i]) * d * d
+	}
+	H /= variance
+	dof := len(samples) - 1
+	p := 1 - ChiSquaredDist{float64(dof)}.CDF(H)
+	return &KruskalWallisTestResult{N: ns, H: H, DoF: dof, P: p}, nil
+}
+
+// DunnTest performs Dunn's test [1] for pairwise differences between
+// the samples following a Kruskal-Wallis test. It returns a matrix
+// of two-sided p-values P, where P[i][j] is the p-value for the null
+// hypothesis that samples i and j are drawn from the same
+// distribution. It uses the ranks of the pooled samples and the
+// tie-corrected variance, so its comparisons are consistent with
+// KruskalWallisTest.
+//
+// The p-values are not adjusted for multiple comparisons. Callers
+// should adjust them, e.g., by Holm's or Bonferroni's method.
+//
+// Returns the same errors as KruskalWallisTest.
+//
+// [1] Dunn, O. J. (1964). "Multiple Comparisons Using Rank Sums".
+// Technometrics 6 (3): 241-252.
+func DunnTest(samples ...[]float64) ([][]float64, error) {
+	meanRanks, variance, err := kwRanks(samples)
+	if err != nil {
+		return nil, err
+	}
+	P := make([][]float64, len(samples))
+	for i := range P {
+		P[i] = make([]float64, len(samples))
+		for j := range P[i] {
+			se := math.Sqrt(variance * (1/float64(len(samples[i])) + 1/float64(len(samples[j]))))
+			z := math.Abs(meanRanks[i]-meanRanks[j]) / se
+			P[i][j] = 2 * (1 - StdNormal.CDF(z))
+		}
+	}
+	return P, nil
+}
+
+// kwRanks ranks the pooled values of samples and returns the mean
+// rank of each sample and the tie-corrected variance of the ranks.
+func kwRanks(samples [][]float64) (meanRanks []float64, variance float64, err error) {
+	if len(samples) < 2 {
+		return nil, 0, ErrSampleSize
+	}
+	var all []float64
+	for _, s := range samples {
+		if len(s) == 0 {
+			return nil, 0, ErrSampleSize
+		}
+		all = append(all, s...)
+	}
+	ranks, ties := midranks(all)
+	N := float64(len(all))
+	variance = N*(N+1)/12 - ties/(12*(N-1))
+	if variance <= 0 {
+		return nil, 0, ErrSamplesEqual
+	}
+	meanRanks = make([]float64, len(samples))
+	i := 0
+	for j, s := range samples {
+		meanRanks[j] = Mean(ranks[i : i+len(s)])
+		i += len(s)
+	}
+	return meanRanks, variance, nil
+}
+
+// midranks returns the ranks of xs from 1 to len(xs), giving tied
+// values the mean of the ranks they span, and the sum of t³ - t over
+// each group of t tied values, which corrects rank statistics for
+// the ties.
+func midranks(xs []float64) (ranks []float64, ties float64) {
+	order := make([]int, len(xs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return xs[order[i]] < xs[order[j]] })
+	ranks = make([]float64, len(xs))
+	for i := 0; i < len(order); {
+		j := i + 1
+		for j < len(order) && xs[order[j]] == xs[order[i]] {
+			j++
+		}
+		// Values order[i:j] are tied at ranks i+1 through j.
+		r := float64(i+1+j) / 2
+		for _, k := range order[i:j] {
+			ranks[k] = r
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	return ranks, ties
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestKruskalWallisTest(t *testing.T) {
+	check := func(samples [][]float64, H, P float64, dunn [][]float64) {
+		t.Helper()
+		r, err := KruskalWallisTest(samples...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aeq(H, r.H) || r.DoF != len(samples)-1 || !aeq(P, r.P) {
+			t.Errorf("KruskalWallisTest(%v) = %+v, want H=%v DoF=%v P=%v", samples, r, H, len(samples)-1, P)
+		}
+		d, err := DunnTest(samples...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range dunn {
+			for j := range dunn[i] {
+				if !aeq(dunn[i][j], d[i][j]) {
+					t.Errorf("DunnTest(%v)[%d][%d] = %v, want %v", samples, i, j, d[i][j], dunn[i][j])
+				}
+			}
+		}
+	}
+
+	// Example from R's kruskal.test documentation (Hollander &
+	// Wolfe, 1973).
+	check([][]float64{
+		{2.9, 3.0, 2.5, 2.6, 3.2},
+		{3.8, 2.7, 4.0, 2.4},
+		{2.8, 3.4, 3.7, 2.2, 2.0},
+	}, 0.7714285714285716, 0.6799647735788937, [][]float64{
+		{1, 0.521245308114815, 0.8205958397554409},
+		{0.521245308114815, 1, 0.3924205244765817},
+		{0.8205958397554409, 0.3924205244765817, 1},
+	})
+
+	// With ties.
+	check([][]float64{
+		{1, 2, 2, 3, 3},
+		{3, 4, 4, 5},
+		{5, 5, 6, 7, 7, 8},
+	}, 11.672616879174258, 0.00291960063880758, [][]float64{
+		{1, 0.14772291851098496, 0.000658430767708662},
+		{0.14772291851098496, 1, 0.09083560967138693},
+		{0.000658430767708662, 0.09083560967138693, 1},
+	})
+
+	if _, err := KruskalWallisTest([]float64{1, 2}); err != ErrSampleSize {
+		t.Errorf("one sample: want ErrSampleSize, got %v", err)
+	}
+	if _, err := KruskalWallisTest([]float64{1, 2}, nil); err != ErrSampleSize {
+		t.Errorf("empty sample: want ErrSampleSize, got %v", err)
+	}
+	if _, err := KruskalWallisTest([]float64{1, 1}, []float64{1}); err != ErrSamplesEqual {
+		t.Errorf("equal values: want ErrSamplesEqual, got %v", err)
+	}
+}
+
+func TestMidranks(t *testing.T) {
+	ranks, ties := midranks([]float64{3, 1, 4, 1, 5, 9, 2, 6, 5})
+	want := []float64{4, 1.5, 5, 1.5, 6.5, 9, 3, 8, 6.5}
+	for i := range want {
+		if ranks[i] != want[i] {
+			t.Errorf("midranks = %v, want %v", ranks, want)
+			break
+		}
+	}
+	if ties != 12 {
+		t.Errorf("midranks ties = %v, want 12", ties)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"rsc.io/benchstat/internal/go-moremath/mathx"
+)
+
+// A MannKendallTestResult is the result of a Mann-Kendall trend test.
+type MannKendallTestResult struct {
+	// N is the size of the input sample.
+	N int
+
+	// S is the Mann-Kendall statistic: the number of pairs of
+	// values in which the later value is greater, minus the
+	// number in which it is less. It is in the range
+	// [-N(N-1)/2, N(N-1)/2].
+	S float64
+
+	// Tau is Kendall's τ-a between the values and their order,
+	// S / (N(N-1)/2).
+	Tau float64
+
+	// AltHypothesis specifies the alternative hypothesis tested
+	// by this test against the null hypothesis that the values
+	// are independent of their order. LocationLess means the
+	// values tend to decrease and LocationGreater means they tend
+	// to increase.
+	AltHypothesis LocationHypothesis
+
+	// P is the p-value of the Mann-Kendall test for the given
+	// null hypothesis.
+	P float64
+}
+
+// MannKendallExactLimit gives the largest sample size for which the
+// exact distribution of S will be used for the Mann-Kendall test.
+var MannKendallExactLimit = 50
+
+// MannKendallTest performs a Mann-Kendall trend test [1] of the null
+// hypothesis that the values of xs, in order, are independent and
+// identically distributed, against the alternative hypothesis that
+// they trend monotonically up or down. This is a rank test for
+// association between the values and their index, so it makes no
+// assumptions about the shape of the distribution or of the trend.
+//
+// If there are no ties and N <= MannKendallExactLimit, this uses the
+// exact distribution of S. Otherwise, it uses a normal approximation
+// with tie and continuity corrections.
+//
+// This can fail with ErrSampleSize if xs has fewer than 2 values, or
+// ErrSamplesEqual if all values are equal.
+//
+// [1] Kendall, M. G. (1975). Rank Correlation Methods. Griffin,
+// London.
+func MannKendallTest(xs []float64, alt LocationHypothesis) (*MannKendallTestResult, error) {
+	n := len(xs)
+	if n < 2 {
+		return nil, ErrSampleSize
+	}
+
+	S := 0
+	for i, xi := range xs {
+		for _, xj := range xs[i+1:] {
+			if xj > xi {
+				S++
+			} else if xj < xi {
+				S--
+			}
+		}
+	}
+
+	// Find the sizes of the groups of tied values.
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	var T []int
+	hasTies := false
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && sorted[j] == sorted[i] {
+			j++
+		}
+		T = append(T, j-i)
+		if j-i > 1 {
+			hasTies = true
+		}
+		i = j
+	}
+	if len(T) == 1 {
+		return nil, ErrSamplesEqual
+	}
+
+	M := n * (n - 1) / 2
+	var p float64
+	if !hasTies && n <= MannKendallExactLimit {
+		// Under the null hypothesis, every permutation is
+		// equally likely and S = M - 2*(number of inversions).
+		pmf := inversionPMF(n)
+		// cdf(s) = P(S <= s) = P(inversions >= (M-s)/2).
+		cdf := func(s int) float64 {
+			sum := 0.0
+			for k := (M - s) / 2; k <= M; k++ {
+				sum += pmf[k]
+			}
+			return sum
+		}
+		switch alt {
+		case LocationDiffers:
+			p = math.Min(1, 2*math.Min(cdf(S), 1-cdf(S-2)))
+		case LocationLess:
+			p = cdf(S)
+		case LocationGreater:
+			p = 1 - cdf(S-2)
+		}
+	} else {
+		fn := float64(n)
+		v := fn * (fn - 1) * (2*fn + 5)
+		for _, t := range T {
+			ft := float64(t)
+			v -= ft * (ft - 1) * (2*ft + 5)
+		}
+		σ := math.Sqrt(v / 18)
+		// S moves in steps of 2, so the continuity correction
+		// is 1.
+		numer := float64(S)
+		switch alt {
+		case LocationDiffers:
+			numer -= mathx.Sign(numer)
+		case LocationLess:
+			numer++
+		case LocationGreater:
+			numer--
+		}
+		z := numer / σ
+		switch alt {
+		case LocationDiffers:
+			p = 2 * math.Min(StdNormal.CDF(z), 1-StdNormal.CDF(z))
+		case LocationLess:
+			p = StdNormal.CDF(z)
+		case LocationGreater:
+			p = 1 - StdNormal.CDF(z)
+		}
+	}
+
+	return &MannKendallTestResult{N: n, S: float64(S), Tau: float64(S) / float64(M),
+		AltHypothesis: alt, P: p}, nil
+}
+
+// inversionPMF returns the probability mass function of the number
+// of inversions in a uniformly random permutation of n elements.
+// These are the Mahonian numbers divided by n!.
+func inversionPMF(n int) []float64 {
+	M := n * (n - 1) / 2
+	pmf := make([]float64, M+1)
+	pmf[0] = 1
+	// Adding the i'th element to a permutation of i-1 elements
+	// adds between 0 and i-1 inversions, with equal probability.
+	for i := 2; i <= n; i++ {
+		next := make([]float64, M+1)
+		for k, p := range pmf {
+			if p == 0 {
+				continue
+			}
+			for j := 0; j < i; j++ {
+				next[k+j] += p / float64(i)
+			}
+		}
+		pmf = next
+	}
+	return pmf
+}
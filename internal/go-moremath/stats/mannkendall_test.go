@@ -0,0 +1,59 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestMannKendallTest(t *testing.T) {
+	check3 := func(xs []float64, S float64, pless, pdiff, pgreater float64) {
+		t.Helper()
+		for _, alt := range []LocationHypothesis{LocationLess, LocationDiffers, LocationGreater} {
+			want := &MannKendallTestResult{N: len(xs), S: S, AltHypothesis: alt}
+			want.Tau = S / float64(len(xs)*(len(xs)-1)/2)
+			want.P = map[LocationHypothesis]float64{LocationLess: pless, LocationDiffers: pdiff, LocationGreater: pgreater}[alt]
+			got, err := MannKendallTest(xs, alt)
+			if err != nil {
+				t.Errorf("MannKendallTest(%v, %v): %v", xs, alt, err)
+				continue
+			}
+			if want.N != got.N || want.S != got.S || !aeq(want.Tau, got.Tau) ||
+				want.AltHypothesis != got.AltHypothesis || !aeq(want.P, got.P) {
+				t.Errorf("want %+v, got %+v", want, got)
+			}
+		}
+	}
+
+	// Exact p-values, checked by enumerating every permutation.
+	check3([]float64{1, 3, 2, 4, 6, 5, 7}, 17, 0.9986111111111111, 0.010714285714285714, 0.005357142857142857)
+	check3([]float64{5, 4, 6, 3, 2, 1}, -11, 0.027777777777777776, 0.05555555555555555, 0.9916666666666667)
+
+	// Ties use the normal approximation.
+	check3([]float64{1, 2, 2, 3, 1, 4, 5, 5, 4, 6, 7, 6}, 49, 0.9997394388001118, 0.0008657464558829986, 0.0004328732279414993)
+
+	if r, err := MannKendallTest([]float64{1, 1, 1}, LocationDiffers); err != ErrSamplesEqual {
+		t.Errorf("want ErrSamplesEqual, got %+v, %+v", r, err)
+	}
+	if r, err := MannKendallTest([]float64{1}, LocationDiffers); err != ErrSampleSize {
+		t.Errorf("want ErrSampleSize, got %+v, %+v", r, err)
+	}
+}
+
+func TestInversionPMF(t *testing.T) {
+	// Mahonian numbers for n = 4, divided by 4!.
+	want := []float64{1, 3, 5, 6, 5, 3, 1}
+	got := inversionPMF(4)
+	for i := range want {
+		want[i] /= 24
+	}
+	if len(got) != len(want) {
+		t.Fatalf("inversionPMF(4) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !aeq(want[i], got[i]) {
+			t.Errorf("inversionPMF(4) = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+)
+
+// NormalMeanPosterior draws from the posterior distribution of the
+// mean of the normal population that x was sampled from, under the
+// noninformative prior p(μ, σ²) ∝ 1/σ² [1].
+//
+// The marginal posterior of the mean is a Student's t distribution
+// with N-1 degrees of freedom, centered on the sample mean and scaled
+// by the standard error s/√N, so this gives the same intervals as a
+// one-sample t-test. Unlike a p-value, the draws can be interpreted
+// directly: for example, the fraction of draws above a threshold is
+// the posterior probability that the mean is above it. Draws for two
+// samples can be combined to find the posterior of, say, the
+// difference of their means.
+//
+// The source of randomness r may be nil, in which case it uses the
+// default global source. It returns nil if x has fewer than 2 values.
+//
+// [1] Gelman, A. et al. (2013). Bayesian Data Analysis, 3rd ed.,
+// section 3.2. CRC Press.
+func NormalMeanPosterior(x TTestSample, draws int, r *rand.Rand) []float64 {
+	n := x.Weight()
+	if n < 2 {
+		return nil
+	}
+	mean, scale := x.Mean(), math.Sqrt(x.Variance()/n)
+	t := Rand(TDist{n - 1})
+	out := make([]float64, draws)
+	for i := range out {
+		out[i] = mean + scale*t(r)
+	}
+	return out
+}
+
+// NormalMeanPosteriorGreater returns the posterior probability that
+// the mean of the normal population x1 was sampled from is greater
+// than the mean of x2's population, under the prior of
+// NormalMeanPosterior. This is the Bayesian counterpart of a
+// one-sided Welch's t-test. It returns NaN if either sample has fewer
+// than 2 values or neither sample has any variance.
+//
+// This integrates over the posterior of the first mean numerically,
+// so unlike a count of draws it is precise even when the probability
+// is close to 0 or 1.
+func NormalMeanPosteriorGreater(x1, x2 TTestSample) float64 {
+	n1, n2 := x1.Weight(), x2.Weight()
+	if n1 < 2 || n2 < 2 {
+		return nan
+	}
+	m1, s1 := x1.Mean(), math.Sqrt(x1.Variance()/n1)
+	m2, s2 := x2.Mean(), math.Sqrt(x2.Variance()/n2)
+	t1, t2 := TDist{n1 - 1}, TDist{n2 - 1}
+	switch {
+	case s1 == 0 && s2 == 0:
+		return nan
+	case s1 == 0:
+		return t2.CDF((m1 - m2) / s2)
+	case s2 == 0:
+		return 1 - t1.CDF((m2-m1)/s1)
+	}
+
+	// P = ∫ t1.PDF(u) t2.CDF((m1 + s1 u - m2) / s2) du. Substitute
+	// u = tan θ to integrate over a finite interval, and use
+	// Simpson's rule.
+	f := func(θ float64) float64 {
+		if math.Abs(θ) >= math.Pi/2 {
+			return 0
+		}
+		u := math.Tan(θ)
+		return t1.PDF(u) * t2.CDF((m1+s1*u-m2)/s2) * (1 + u*u)
+	}
+	const steps = 2000
+	h := math.Pi / steps
+	sum := f(-math.Pi/2) + f(math.Pi/2)
+	for i := 1; i < steps; i++ {
+		w := 2.0
+		if i%2 == 1 {
+			w = 4
+		}
+		sum += w * f(-math.Pi/2+float64(i)*h)
+	}
+	return math.Max(0, math.Min(1, sum*h/3))
+}
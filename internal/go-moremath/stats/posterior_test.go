@@ -0,0 +1,74 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNormalMeanPosterior(t *testing.T) {
+	x := Sample{Xs: []float64{1, 2, 4, 8, 16}}
+	r := rand.New(rand.NewSource(1))
+	draws := NormalMeanPosterior(x, 20000, r)
+	if len(draws) != 20000 {
+		t.Fatalf("got %d draws, want 20000", len(draws))
+	}
+
+	// The quantiles of the draws should match the shifted and
+	// scaled t distribution.
+	mean, scale := x.Mean(), x.StdDev()/math.Sqrt(5)
+	inv := InvCDF(TDist{4})
+	s := Sample{Xs: draws}
+	s.Sort()
+	for _, q := range []float64{0.025, 0.25, 0.5, 0.75, 0.975} {
+		want := mean + scale*inv(q)
+		if got := s.Percentile(q); math.Abs(got-want) > 0.1*scale {
+			t.Errorf("quantile %v of draws = %v, want %v", q, got, want)
+		}
+	}
+
+	if draws := NormalMeanPosterior(Sample{Xs: []float64{1}}, 10, r); draws != nil {
+		t.Errorf("NormalMeanPosterior of one value = %v, want nil", draws)
+	}
+}
+
+func TestNormalMeanPosteriorGreater(t *testing.T) {
+	x1 := Sample{Xs: []float64{1, 2, 4, 8, 16}}
+	x2 := Sample{Xs: []float64{3, 5, 7}}
+	if got := NormalMeanPosteriorGreater(x1, x1); !aeq(0.5, got) {
+		t.Errorf("P(μ1 > μ1) = %v, want 0.5", got)
+	}
+	p := NormalMeanPosteriorGreater(x1, x2)
+	if q := NormalMeanPosteriorGreater(x2, x1); !aeq(1, p+q) {
+		t.Errorf("P(μ1 > μ2) + P(μ2 > μ1) = %v + %v, want 1", p, q)
+	}
+
+	// Compare with the fraction of draws.
+	r := rand.New(rand.NewSource(1))
+	d1 := NormalMeanPosterior(x1, 100000, r)
+	d2 := NormalMeanPosterior(x2, 100000, r)
+	greater := 0
+	for i := range d1 {
+		if d1[i] > d2[i] {
+			greater++
+		}
+	}
+	if want := float64(greater) / float64(len(d1)); math.Abs(p-want) > 0.005 {
+		t.Errorf("P(μ1 > μ2) = %v, want about %v", p, want)
+	}
+
+	// With no variance in one sample, this is a one-sample
+	// t-test.
+	c := Sample{Xs: []float64{5, 5}}
+	want := 1 - TDist{4}.CDF((5-x1.Mean())/(x1.StdDev()/math.Sqrt(5)))
+	if got := NormalMeanPosteriorGreater(x1, c); !aeq(want, got) {
+		t.Errorf("P(μ1 > 5) = %v, want %v", got, want)
+	}
+	if got := NormalMeanPosteriorGreater(c, c); !math.IsNaN(got) {
+		t.Errorf("P(μ1 > μ2) with no variance = %v, want NaN", got)
+	}
+}
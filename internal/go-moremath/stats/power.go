@@ -0,0 +1,244 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SampleSizeLimit is the largest sample size the sample size
+// functions will consider. If the requested power cannot be reached
+// with samples of this size, they return 0.
+var SampleSizeLimit = 1000
+
+// WelchTTestPower returns the power of a two-sample Welch's t-test at
+// significance level alpha for samples of sizes n1 and n2 from normal
+// populations with standard deviations σ1 and σ2 whose means differ by
+// δ = μ1 - μ2. That is, it returns the probability that the test
+// rejects the null hypothesis in favor of alt.
+//
+// This approximates the non-central t distribution of the test
+// statistic by a shifted central t distribution, which is accurate to
+// within about a percent for the sample sizes of interest.
+func WelchTTestPower(δ, σ1, σ2 float64, n1, n2 int, alpha float64, alt LocationHypothesis) float64 {
+	if n1 <= 1 || n2 <= 1 {
+		return nan
+	}
+	v1, v2 := σ1*σ1/float64(n1), σ2*σ2/float64(n2)
+	se := math.Sqrt(v1 + v2)
+	if se == 0 {
+		return nan
+	}
+	dof := (v1 + v2) * (v1 + v2) / (v1*v1/float64(n1-1) + v2*v2/float64(n2-1))
+	dist := TDist{dof}
+	inv := InvCDF(dist)
+	ncp := δ / se
+	switch alt {
+	case LocationLess:
+		return dist.CDF(inv(alpha) - ncp)
+	case LocationGreater:
+		return 1 - dist.CDF(inv(1-alpha)-ncp)
+	default:
+		crit := inv(1 - alpha/2)
+		return dist.CDF(-crit-ncp) + 1 - dist.CDF(crit-ncp)
+	}
+}
+
+// WelchTTestSampleSize returns the smallest sample size n such that a
+// two-sample Welch's t-test of two samples of size n has at least the
+// given power. The arguments are as for WelchTTestPower. If no sample
+// size up to SampleSizeLimit reaches the given power, it returns 0.
+func WelchTTestSampleSize(δ, σ1, σ2, alpha, power float64, alt LocationHypothesis) int {
+	return sampleSize(func(n int) float64 {
+		return WelchTTestPower(δ, σ1, σ2, n, n, alpha, alt)
+	}, power)
+}
+
+// WelchTTestMinEffect returns the minimum detectable effect of a
+// two-sample Welch's t-test: the smallest δ ≥ 0 such that the test has
+// at least the given power to detect a difference of δ (or -δ if alt
+// is LocationLess) in the means. The arguments are as for
+// WelchTTestPower. It returns NaN if power is not in (0, 1) or no
+// effect reaches it.
+func WelchTTestMinEffect(σ1, σ2 float64, n1, n2 int, alpha, power float64, alt LocationHypothesis) float64 {
+	if !(0 < power && power < 1) {
+		return nan
+	}
+	sign := 1.0
+	if alt == LocationLess {
+		sign = -1
+	}
+	f := func(δ float64) float64 {
+		return WelchTTestPower(sign*δ, σ1, σ2, n1, n2, alpha, alt)
+	}
+	if math.IsNaN(f(0)) {
+		return nan
+	}
+	// Bracket the effect, then bisect. The power tends to 1 as δ
+	// grows, but give up rather than spin if rounding keeps it
+	// short.
+	hi := math.Sqrt(σ1*σ1/float64(n1) + σ2*σ2/float64(n2))
+	if !(hi > 0) {
+		return nan
+	}
+	for i := 0; f(hi) < power; i++ {
+		if i == 100 {
+			return nan
+		}
+		hi *= 2
+	}
+	_, hi = bisectBool(func(δ float64) bool { return f(δ) >= power }, 0, hi, hi*1e-6)
+	return hi
+}
+
+// MannWhitneyUTestPower estimates the power of a Mann-Whitney U-test
+// at significance level alpha for samples of sizes n1 and n2 drawn
+// from distributions d1 and d2. It simulates the given number of
+// trials, drawing samples using Rand. The source of randomness r may
+// be nil, in which case it uses the default global source.
+//
+// This assumes d1 and d2 are continuous, so the samples have no ties.
+func MannWhitneyUTestPower(d1, d2 DistCommon, n1, n2 int, alpha float64, alt LocationHypothesis, trials int, r *rand.Rand) float64 {
+	if n1 == 0 || n2 == 0 || trials <= 0 {
+		return nan
+	}
+
+	// Find the test's rejection rule. For small samples, this is
+	// the critical value of U from the exact distribution.
+	exact := n1 <= MannWhitneyExactLimit && n2 <= MannWhitneyExactLimit
+	var ucrit float64
+	if exact {
+		a := alpha
+		if alt == LocationDiffers {
+			a /= 2
+		}
+		// Find the largest U with CDF(U) <= a.
+		pmf := UDist{N1: n1, N2: n2}.p(n1 * n2 / 2)
+		ucrit = -1
+		cdf := 0.0
+		for u, p := range pmf {
+			cdf += p
+			if cdf > a {
+				break
+			}
+			ucrit = float64(u)
+		}
+	}
+	reject := func(U1 float64) bool {
+		U2 := float64(n1*n2) - U1
+		if exact {
+			switch alt {
+			case LocationLess:
+				return U1 <= ucrit
+			case LocationGreater:
+				return U2 <= ucrit
+			default:
+				return math.Min(U1, U2) <= ucrit
+			}
+		}
+		// Normal approximation with continuity correction.
+		N := float64(n1 + n2)
+		μ := float64(n1*n2) / 2
+		σ := math.Sqrt(float64(n1*n2) * (N + 1) / 12)
+		var p float64
+		switch alt {
+		case LocationLess:
+			p = StdNormal.CDF((U1 - μ + 0.5) / σ)
+		case LocationGreater:
+			p = 1 - StdNormal.CDF((U1-μ-0.5)/σ)
+		default:
+			z := (math.Abs(U1-μ) - 0.5) / σ
+			p = 2 * (1 - StdNormal.CDF(z))
+		}
+		return p <= alpha
+	}
+
+	rand1, rand2 := Rand(d1), Rand(d2)
+	x1, x2 := make([]float64, n1), make([]float64, n2)
+	rejected := 0
+	for trial := 0; trial < trials; trial++ {
+		for i := range x1 {
+			x1[i] = rand1(r)
+		}
+		for i := range x2 {
+			x2[i] = rand2(r)
+		}
+		if reject(uStatistic(x1, x2)) {
+			rejected++
+		}
+	}
+	return float64(rejected) / float64(trials)
+}
+
+// MannWhitneyUTestSampleSize estimates the smallest sample size n such
+// that a Mann-Whitney U-test of two samples of size n has at least the
+// given power. The arguments are as for MannWhitneyUTestPower. If no
+// sample size up to SampleSizeLimit reaches the given power, it
+// returns 0.
+//
+// Every simulation uses the same stream of random numbers (seeded from
+// r), so the estimated power increases smoothly with n.
+func MannWhitneyUTestSampleSize(d1, d2 DistCommon, alpha, power float64, alt LocationHypothesis, trials int, r *rand.Rand) int {
+	var seed int64
+	if r == nil {
+		seed = rand.Int63()
+	} else {
+		seed = r.Int63()
+	}
+	return sampleSize(func(n int) float64 {
+		r := rand.New(rand.NewSource(seed))
+		return MannWhitneyUTestPower(d1, d2, n, n, alpha, alt, trials, r)
+	}, power)
+}
+
+// uStatistic returns the Mann-Whitney U statistic of x1 and x2,
+// counting ties as 0.5. It sorts x1 and x2 in place.
+func uStatistic(x1, x2 []float64) float64 {
+	sort.Float64s(x1)
+	sort.Float64s(x2)
+	U := 0.0
+	lo, hi := 0, 0 // x2[:lo] < x, x2[:hi] <= x
+	for _, x := range x1 {
+		for lo < len(x2) && x2[lo] < x {
+			lo++
+		}
+		if hi < lo {
+			hi = lo
+		}
+		for hi < len(x2) && x2[hi] <= x {
+			hi++
+		}
+		U += float64(lo) + float64(hi-lo)/2
+	}
+	return U
+}
+
+// sampleSize returns the smallest n in [2, SampleSizeLimit] such that
+// power(n) >= target, assuming power is increasing, or 0 if there is
+// no such n.
+func sampleSize(power func(n int) float64, target float64) int {
+	if !(power(SampleSizeLimit) >= target) {
+		return 0
+	}
+	lo, hi := 1, SampleSizeLimit // power(lo) < target <= power(hi)
+	for n := 2; n < hi; n *= 2 {
+		if power(n) >= target {
+			hi = n
+			break
+		}
+		lo = n
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if power(mid) >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWelchTTestPower(t *testing.T) {
+	near := func(want, got, tol float64) bool {
+		return math.Abs(want-got) <= tol
+	}
+
+	// Compare with R's power.t.test, which uses the exact
+	// non-central t distribution.
+	if got := WelchTTestPower(1, 1, 1, 10, 10, 0.05, LocationDiffers); !near(0.5619846, got, 0.01) {
+		t.Errorf("WelchTTestPower(n=10, δ=1) = %v, want ≈0.5619846", got)
+	}
+	if got := WelchTTestPower(-0.5, 1, 1, 20, 20, 0.05, LocationDiffers); !near(0.3377084, got, 0.01) {
+		t.Errorf("WelchTTestPower(n=20, δ=-0.5) = %v, want ≈0.3377084", got)
+	}
+	if got := WelchTTestPower(0, 1, 1, 10, 10, 0.05, LocationDiffers); !near(0.05, got, 1e-6) {
+		t.Errorf("WelchTTestPower(δ=0) = %v, want 0.05", got)
+	}
+	if got := WelchTTestPower(0, 1, 1, 10, 10, 0.05, LocationLess); !near(0.05, got, 1e-6) {
+		t.Errorf("WelchTTestPower(δ=0, LocationLess) = %v, want 0.05", got)
+	}
+	if less, greater := WelchTTestPower(-1, 1, 2, 10, 10, 0.05, LocationLess), WelchTTestPower(1, 1, 2, 10, 10, 0.05, LocationGreater); !aeq(less, greater) {
+		t.Errorf("one-sided powers are not symmetric: %v != %v", less, greater)
+	}
+
+	if got := WelchTTestSampleSize(1, 1, 1, 0.05, 0.8, LocationDiffers); got != 17 {
+		t.Errorf("WelchTTestSampleSize(δ=1) = %v, want 17", got)
+	}
+	if got := WelchTTestSampleSize(0, 1, 1, 0.05, 0.8, LocationDiffers); got != 0 {
+		t.Errorf("WelchTTestSampleSize(δ=0) = %v, want 0", got)
+	}
+	if got := WelchTTestMinEffect(1, 1, 10, 10, 0.05, 0.8, LocationDiffers); !near(1.324947, got, 0.02) {
+		t.Errorf("WelchTTestMinEffect(n=10) = %v, want ≈1.324947", got)
+	}
+	for _, power := range []float64{0, 1, 80} {
+		if got := WelchTTestMinEffect(1, 1, 10, 10, 0.05, power, LocationDiffers); !math.IsNaN(got) {
+			t.Errorf("WelchTTestMinEffect(power=%v) = %v, want NaN", power, got)
+		}
+	}
+	δ := WelchTTestMinEffect(1, 1, 10, 10, 0.05, 0.8, LocationLess)
+	if got := WelchTTestPower(-δ, 1, 1, 10, 10, 0.05, LocationLess); !near(0.8, got, 1e-4) {
+		t.Errorf("power at minimum effect %v is %v, want 0.8", δ, got)
+	}
+}
+
+func TestMannWhitneyUTestPower(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	// Under the null hypothesis, the power is the actual size of
+	// the test, which is a little under alpha for the exact test.
+	p := MannWhitneyUTestPower(StdNormal, StdNormal, 10, 10, 0.05, LocationDiffers, 4000, r)
+	if p > 0.06 || p < 0.03 {
+		t.Errorf("power under the null hypothesis is %v, want ≈0.043", p)
+	}
+
+	// The asymptotic relative efficiency of the U-test on normal
+	// data is 0.955, so it has slightly less power than the
+	// t-test.
+	shifted := NormalDist{1, 1}
+	want := WelchTTestPower(-1, 1, 1, 10, 10, 0.05, LocationDiffers)
+	p = MannWhitneyUTestPower(StdNormal, shifted, 10, 10, 0.05, LocationDiffers, 4000, r)
+	if p > want || p < want-0.08 {
+		t.Errorf("power for a 1σ shift is %v, want slightly less than %v", p, want)
+	}
+	p = MannWhitneyUTestPower(StdNormal, shifted, 100, 100, 0.05, LocationLess, 1000, r)
+	if p < 0.99 {
+		t.Errorf("power for a 1σ shift with n=100 is %v, want ≈1", p)
+	}
+	p = MannWhitneyUTestPower(StdNormal, shifted, 100, 100, 0.05, LocationGreater, 1000, r)
+	if p > 0.01 {
+		t.Errorf("power for a 1σ shift in the wrong direction is %v, want ≈0", p)
+	}
+
+	n := MannWhitneyUTestSampleSize(StdNormal, shifted, 0.05, 0.8, LocationDiffers, 1000, r)
+	if n < 17 || n > 20 {
+		t.Errorf("sample size for a 1σ shift is %v, want ≈18", n)
+	}
+}
+
+func TestUStatistic(t *testing.T) {
+	for _, test := range []struct {
+		x1, x2 []float64
+		U      float64
+	}{
+		{[]float64{2, 1, 3, 5}, []float64{12, 11, 13, 15}, 0},
+		{[]float64{12, 11, 13, 15}, []float64{2, 1, 3, 5}, 16},
+		{[]float64{2, 1, 3, 5}, []float64{0, 4, 6, 7}, 5},
+		{[]float64{2, 1, 3, 5}, []float64{2, 2, 2, 2}, 10},
+	} {
+		want, _ := MannWhitneyUTest(test.x1, test.x2, LocationDiffers)
+		if got := uStatistic(test.x1, test.x2); got != test.U || got != want.U {
+			t.Errorf("uStatistic(%v, %v) = %v, want %v", test.x1, test.x2, got, test.U)
+		}
+	}
+}
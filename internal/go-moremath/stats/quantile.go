@@ -0,0 +1,306 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+
+	"rsc.io/benchstat/internal/go-moremath/mathx"
+)
+
+// A QuantileMethod is a method of estimating a quantile of a
+// population from a sample.
+type QuantileMethod int
+
+//go:generate stringer -type QuantileMethod
+
+const (
+	// QuantileR1 through QuantileR9 are the nine sample quantile
+	// definitions of Hyndman and Fan [1], numbered as in R's
+	// quantile function. Types 1-3 return one of the sample
+	// values; types 4-9 interpolate linearly between two
+	// neighboring order statistics. Percentile uses QuantileR8,
+	// which Hyndman and Fan recommend because it is approximately
+	// median-unbiased for any distribution.
+	//
+	// [1] Hyndman, R. J. and Fan, Y. (1996). "Sample quantiles in
+	// statistical packages". The American Statistician 50 (4):
+	// 361-365.
+	QuantileR1 QuantileMethod = 1 + iota
+	QuantileR2
+	QuantileR3
+	QuantileR4
+	QuantileR5
+	QuantileR6
+	QuantileR7
+	QuantileR8
+	QuantileR9
+
+	// QuantileHarrellDavis is the Harrell-Davis estimator. See
+	// Sample.HarrellDavis.
+	QuantileHarrellDavis
+)
+
+// Quantile returns the q'th quantile of the Sample, estimated using
+// method m.
+//
+// q will be capped to the range [0, 1]. If len(xs) == 0, returns NaN.
+func (s Sample) Quantile(q float64, m QuantileMethod) float64 {
+	if len(s.Xs) == 0 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted quantiles.
+		panic("Weighted Quantile not implemented")
+	}
+	if m == QuantileHarrellDavis {
+		return s.HarrellDavis(q)
+	}
+	if !s.Sorted {
+		s = *s.Copy().Sort()
+	}
+	q = math.Max(0, math.Min(1, q))
+	n := float64(len(s.Xs))
+	// x returns the k'th order statistic, where x(1) is the
+	// minimum, clamping k to the sample.
+	x := func(k float64) float64 {
+		i := int(math.Max(1, math.Min(n, k)))
+		return s.Xs[i-1]
+	}
+
+	switch m {
+	case QuantileR1, QuantileR2, QuantileR3:
+		nq := n * q
+		if m == QuantileR3 {
+			nq -= 0.5
+		}
+		j := math.Floor(nq)
+		switch {
+		case nq > j:
+			return x(j + 1)
+		case m == QuantileR2:
+			return (x(j) + x(j+1)) / 2
+		case m == QuantileR3 && math.Mod(j, 2) == 1:
+			// Round to the even order statistic.
+			return x(j + 1)
+		}
+		return x(j)
+	}
+
+	var h float64
+	switch m {
+	case QuantileR4:
+		h = n * q
+	case QuantileR5:
+		h = n*q + 0.5
+	case QuantileR6:
+		h = (n + 1) * q
+	case QuantileR7:
+		h = (n-1)*q + 1
+	case QuantileR8:
+		h = (n+1.0/3)*q + 1.0/3
+	case QuantileR9:
+		h = (n+0.25)*q + 3.0/8
+	default:
+		panic("unknown quantile method")
+	}
+	h = math.Max(1, math.Min(n, h))
+	j := math.Floor(h)
+	return x(j) + (h-j)*(x(j+1)-x(j))
+}
+
+// QuantileStdErr returns an estimate of the standard error of
+// s.Quantile(q, m). It returns NaN if the Sample has fewer than 2
+// values.
+//
+// For QuantileHarrellDavis, this is HarrellDavisStdErr. For the
+// Hyndman-Fan methods, it is the Maritz-Jarrett estimate [1] of the
+// standard error of the order statistic nearest the estimate, which
+// uses the beta distribution of the order statistic to weight the
+// sample.
+//
+// [1] Maritz, J. S. and Jarrett, R. G. (1978). "A note on estimating
+// the variance of the sample median". Journal of the American
+// Statistical Association 73 (361): 194-196.
+func (s Sample) QuantileStdErr(q float64, m QuantileMethod) float64 {
+	n := len(s.Xs)
+	if n < 2 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted quantiles.
+		panic("Weighted Quantile not implemented")
+	}
+	if m == QuantileHarrellDavis {
+		return s.HarrellDavisStdErr(q)
+	}
+	if !s.Sorted {
+		s = *s.Copy().Sort()
+	}
+
+	// The k'th order statistic of a sample of n is F⁻¹(U) where U
+	// has a Beta(k, n-k+1) distribution. Estimate its moments by
+	// substituting the sample quantile function for F⁻¹.
+	q = math.Max(0, math.Min(1, q))
+	k := math.Max(1, math.Min(float64(n), math.Floor(q*float64(n)+0.5)))
+	a, b := k, float64(n)-k+1
+	c1, c2, prev := 0.0, 0.0, 0.0
+	for i, x := range s.Xs {
+		cdf := mathx.BetaInc(float64(i+1)/float64(n), a, b)
+		w := cdf - prev
+		prev = cdf
+		c1 += w * x
+		c2 += w * x * x
+	}
+	return math.Sqrt(math.Max(0, c2-c1*c1))
+}
+
+// HarrellDavis returns the Harrell-Davis estimate [1] of the q'th
+// quantile of the population the Sample was drawn from. This is a
+// weighted average of all of the order statistics, with weights from
+// a beta distribution centered near q, so it varies more smoothly
+// with the data than Percentile, which interpolates between just two
+// of them. This makes it more efficient for small samples and for
+// quantiles in the tails.
+//
+// q will be capped to the range [0, 1]. If len(xs) == 0, returns NaN.
+//
+// [1] Harrell, F. E. and Davis, C. E. (1982). "A new
+// distribution-free quantile estimator". Biometrika 69 (3): 635-640.
+func (s Sample) HarrellDavis(q float64) float64 {
+	if len(s.Xs) == 0 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted Harrell-Davis.
+		panic("Weighted HarrellDavis not implemented")
+	}
+	if !s.Sorted {
+		s = *s.Copy().Sort()
+	}
+	w := hdWeights(len(s.Xs), q)
+	est := 0.0
+	for i, x := range s.Xs {
+		est += w[i] * x
+	}
+	// The weights sum to 1 only up to rounding error, which
+	// could put the estimate outside the sample.
+	return math.Max(s.Xs[0], math.Min(est, s.Xs[len(s.Xs)-1]))
+}
+
+// HarrellDavisStdErr returns the jackknife estimate of the standard
+// error of s.HarrellDavis(q), as proposed by Harrell and Davis. It
+// returns NaN if the Sample has fewer than 2 values.
+func (s Sample) HarrellDavisStdErr(q float64) float64 {
+	n := len(s.Xs)
+	if n < 2 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted Harrell-Davis.
+		panic("Weighted HarrellDavis not implemented")
+	}
+	if !s.Sorted {
+		s = *s.Copy().Sort()
+	}
+	xs := s.Xs
+
+	// Leaving out xs[j] leaves the order statistics xs[:j] and
+	// xs[j+1:], which get the weights for a sample of n-1.
+	// Accumulate prefix and suffix sums so that each of the n
+	// leave-one-out estimates takes constant time.
+	w := hdWeights(n-1, q)
+	suffix := make([]float64, n) // suffix[j] = Σ_{i>=j} w[i] xs[i+1]
+	for i := n - 2; i >= 0; i-- {
+		suffix[i] = suffix[i+1] + w[i]*xs[i+1]
+	}
+	ests := make([]float64, n)
+	prefix := 0.0 // Σ_{i<j} w[i] xs[i]
+	for j := range ests {
+		ests[j] = prefix + suffix[j]
+		if j < n-1 {
+			prefix += w[j] * xs[j]
+		}
+	}
+
+	mean := Mean(ests)
+	ss := 0.0
+	for _, est := range ests {
+		ss += (est - mean) * (est - mean)
+	}
+	return math.Sqrt(float64(n-1) / float64(n) * ss)
+}
+
+// hdWeights returns the Harrell-Davis weights of the order
+// statistics of a sample of size n for the q'th quantile.
+func hdWeights(n int, q float64) []float64 {
+	w := make([]float64, n)
+	q = math.Max(0, math.Min(1, q))
+	switch {
+	case q == 0:
+		w[0] = 1
+		return w
+	case q == 1:
+		w[n-1] = 1
+		return w
+	}
+	a, b := q*float64(n+1), (1-q)*float64(n+1)
+	prev := 0.0
+	for i := range w {
+		cdf := mathx.BetaInc(float64(i+1)/float64(n), a, b)
+		w[i] = cdf - prev
+		prev = cdf
+	}
+	return w
+}
+
+// A QuantileTestResult is the result of a test comparing a quantile of
+// two samples.
+type QuantileTestResult struct {
+	// N1 and N2 are the sizes of the input samples.
+	N1, N2 int
+
+	// Q is the quantile compared.
+	Q float64
+
+	// Diff is the difference between the Harrell-Davis estimates
+	// of the quantile of the two samples, x1 - x2, and StdErr is
+	// its standard error.
+	Diff, StdErr float64
+
+	// P is the p-value of the test against the null hypothesis
+	// that the q'th quantiles of the two populations are the
+	// same.
+	P float64
+}
+
+// QuantileDifferenceTest tests the null hypothesis that the q'th
+// quantiles of the populations x1 and x2 were drawn from are equal,
+// against the alternative hypothesis that they differ.
+//
+// This compares the Harrell-Davis estimates of the quantile, using
+// the jackknife estimates of their standard errors and a normal
+// approximation to the distribution of the difference. This is
+// accurate for moderate to large samples; with samples of fewer than
+// 20 or so values, p-values for quantiles in the tails are optimistic.
+//
+// This can fail with ErrSampleSize if either sample has fewer than 2
+// values, or ErrZeroVariance if both estimates have no variance.
+func QuantileDifferenceTest(x1, x2 Sample, q float64) (*QuantileTestResult, error) {
+	n1, n2 := len(x1.Xs), len(x2.Xs)
+	if n1 < 2 || n2 < 2 {
+		return nil, ErrSampleSize
+	}
+	x1, x2 = *x1.Copy().Sort(), *x2.Copy().Sort()
+	diff := x1.HarrellDavis(q) - x2.HarrellDavis(q)
+	se1, se2 := x1.HarrellDavisStdErr(q), x2.HarrellDavisStdErr(q)
+	se := math.Sqrt(se1*se1 + se2*se2)
+	if se == 0 {
+		return nil, ErrZeroVariance
+	}
+	z := diff / se
+	p := 2 * (1 - StdNormal.CDF(math.Abs(z)))
+	return &QuantileTestResult{N1: n1, N2: n2, Q: q, Diff: diff, StdErr: se, P: p}, nil
+}
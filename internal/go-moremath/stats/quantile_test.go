@@ -0,0 +1,112 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestHarrellDavis(t *testing.T) {
+	s := Sample{Xs: []float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1}}
+	for _, test := range []struct{ q, est, se float64 }{
+		{0.1, 1.6725295470104076, 0.740199796730341},
+		{0.5, 4.140316199896188, 0.8386399473033933},
+		{0.9, 7.71234463959644, 0.7145120144270285},
+	} {
+		if got := s.HarrellDavis(test.q); !aeq(test.est, got) {
+			t.Errorf("HarrellDavis(%v) = %v, want %v", test.q, got, test.est)
+		}
+		if got := s.HarrellDavisStdErr(test.q); !aeq(test.se, got) {
+			t.Errorf("HarrellDavisStdErr(%v) = %v, want %v", test.q, got, test.se)
+		}
+	}
+	if got := s.HarrellDavis(0); got != 1.2 {
+		t.Errorf("HarrellDavis(0) = %v, want 1.2", got)
+	}
+	if got := s.HarrellDavis(1); got != 8.1 {
+		t.Errorf("HarrellDavis(1) = %v, want 8.1", got)
+	}
+	for _, x := range []float64{80, 800000, 0.1} {
+		c := Sample{Xs: []float64{x, x, x, x, x, x}}
+		for _, q := range []float64{0.25, 0.5, 0.75} {
+			if got := c.HarrellDavis(q); got != x {
+				t.Errorf("HarrellDavis(%v) of constant sample %v = %v, want %v", q, x, got, x)
+			}
+		}
+	}
+}
+
+func TestQuantileDifferenceTest(t *testing.T) {
+	xs := []float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1}
+	var ys []float64
+	for _, x := range xs {
+		ys = append(ys, x+1.5)
+	}
+	ys = append(ys, 9.0, 4.0)
+	want := &QuantileTestResult{N1: 10, N2: 12, Q: 0.5,
+		Diff: -1.5437360114786545, StdErr: 1.2559313800620286, P: 0.21901319692317123}
+	got, err := QuantileDifferenceTest(Sample{Xs: xs}, Sample{Xs: ys}, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.N1 != got.N1 || want.N2 != got.N2 || want.Q != got.Q ||
+		!aeq(want.Diff, got.Diff) || !aeq(want.StdErr, got.StdErr) || !aeq(want.P, got.P) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	c := Sample{Xs: []float64{1, 1, 1}}
+	if r, err := QuantileDifferenceTest(c, c, 0.5); err != ErrZeroVariance {
+		t.Errorf("want ErrZeroVariance, got %+v, %+v", r, err)
+	}
+}
+
+func TestSampleQuantile(t *testing.T) {
+	s := Sample{Xs: []float64{35, 15, 50, 20, 40}}
+	qs := []float64{0.05, 0.3, 0.4, 0.5, 0.95}
+	// Computed from the definitions in Hyndman and Fan. Types 6
+	// and 7 agree with Python's statistics.quantiles "exclusive"
+	// and "inclusive" methods, and type 8 with Percentile.
+	want := map[QuantileMethod][]float64{
+		QuantileR1: {15, 20, 20, 35, 50},
+		QuantileR2: {15, 20, 27.5, 35, 50},
+		QuantileR3: {15, 20, 20, 20, 50},
+		QuantileR4: {15, 17.5, 20, 27.5, 47.5},
+		QuantileR5: {15, 20, 27.5, 35, 50},
+		QuantileR6: {15, 19, 26, 35, 50},
+		QuantileR7: {16, 23, 29, 35, 48},
+		QuantileR8: {15, 19.666666666666664, 27, 35, 50},
+		QuantileR9: {15, 19.75, 27.125, 35, 50},
+	}
+	for m, ws := range want {
+		for i, q := range qs {
+			if got := s.Quantile(q, m); !aeq(ws[i], got) {
+				t.Errorf("Quantile(%v, %v) = %v, want %v", q, m, got, ws[i])
+			}
+		}
+		if got := s.Quantile(0, m); got != 15 {
+			t.Errorf("Quantile(0, %v) = %v, want 15", m, got)
+		}
+		if got := s.Quantile(1, m); got != 50 {
+			t.Errorf("Quantile(1, %v) = %v, want 50", m, got)
+		}
+	}
+	if got, want := s.Quantile(0.3, QuantileHarrellDavis), s.HarrellDavis(0.3); got != want {
+		t.Errorf("Quantile(0.3, QuantileHarrellDavis) = %v, want %v", got, want)
+	}
+}
+
+func TestSampleQuantileStdErr(t *testing.T) {
+	s := Sample{Xs: []float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1}}
+	for _, test := range []struct{ q, se float64 }{
+		{0.25, 0.7715634461062632},
+		{0.5, 0.9336327368653209},
+		{0.9, 1.1796802078867672},
+	} {
+		if got := s.QuantileStdErr(test.q, QuantileR8); !aeq(test.se, got) {
+			t.Errorf("QuantileStdErr(%v, QuantileR8) = %v, want %v", test.q, got, test.se)
+		}
+	}
+	if got, want := s.QuantileStdErr(0.5, QuantileHarrellDavis), s.HarrellDavisStdErr(0.5); got != want {
+		t.Errorf("QuantileStdErr(0.5, QuantileHarrellDavis) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "math"
+
+// A LinearRegressionResult is the least-squares fit of a line
+// y = Intercept + Slope*x to a set of points.
+type LinearRegressionResult struct {
+	// N is the number of points.
+	N int
+
+	// Slope and Intercept are the coefficients of the fitted line.
+	Slope, Intercept float64
+
+	// SlopeStdErr is the standard error of Slope.
+	SlopeStdErr float64
+
+	// ResidualStdErr is the standard deviation of the residuals,
+	// estimated with N-2 degrees of freedom.
+	ResidualStdErr float64
+
+	// R2 is the coefficient of determination: the fraction of the
+	// variance of y explained by the fit.
+	R2 float64
+
+	// xMean and sxx are the mean of x and the sum of squared
+	// deviations from it.
+	xMean, sxx float64
+}
+
+// LinearRegression fits a line to the points (xs[i], ys[i]) by
+// ordinary least squares. The standard errors and intervals it gives
+// assume that the residuals are independent and normally distributed
+// with constant variance.
+//
+// Returns ErrMismatchedSamples if xs and ys have different lengths,
+// ErrSampleSize if there are fewer than three points, or
+// ErrZeroVariance if all xs are equal.
+func LinearRegression(xs, ys []float64) (*LinearRegressionResult, error) {
+	if len(xs) != len(ys) {
+		return nil, ErrMismatchedSamples
+	}
+	n := len(xs)
+	if n < 3 {
+		return nil, ErrSampleSize
+	}
+	xm, ym := Mean(xs), Mean(ys)
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-xm, ys[i]-ym
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return nil, ErrZeroVariance
+	}
+	slope := sxy / sxx
+	sse := math.Max(syy-slope*sxy, 0)
+	s := math.Sqrt(sse / float64(n-2))
+	r2 := 1.0
+	if syy > 0 {
+		r2 = 1 - sse/syy
+	}
+	return &LinearRegressionResult{
+		N:              n,
+		Slope:          slope,
+		Intercept:      ym - slope*xm,
+		SlopeStdErr:    s / math.Sqrt(sxx),
+		ResidualStdErr: s,
+		R2:             r2,
+		xMean:          xm,
+		sxx:            sxx,
+	}, nil
+}
+
+// DoF returns the residual degrees of freedom of the fit.
+func (r *LinearRegressionResult) DoF() float64 {
+	return float64(r.N - 2)
+}
+
+// SlopeInterval returns a confidence interval for the slope at the
+// given confidence level (e.g., 0.95).
+func (r *LinearRegressionResult) SlopeInterval(confidence float64) (lo, hi float64) {
+	t := InvCDF(TDist{r.DoF()})(1 - (1-confidence)/2)
+	return r.Slope - t*r.SlopeStdErr, r.Slope + t*r.SlopeStdErr
+}
+
+// Predict returns the value of the fitted line at x.
+func (r *LinearRegressionResult) Predict(x float64) float64 {
+	return r.Intercept + r.Slope*x
+}
+
+// PredictInterval returns a confidence interval at the given
+// confidence level for the mean of y at x. Evaluated over a range of
+// x, these form the confidence band of the fitted line, which is
+// narrowest at the mean of the xs.
+func (r *LinearRegressionResult) PredictInterval(x, confidence float64) (lo, hi float64) {
+	dx := x - r.xMean
+	se := r.ResidualStdErr * math.Sqrt(1/float64(r.N)+dx*dx/r.sxx)
+	t := InvCDF(TDist{r.DoF()})(1 - (1-confidence)/2)
+	y := r.Predict(x)
+	return y - t*se, y + t*se
+}
+
+// RegressionSlopeTest tests the null hypothesis that the lines fitted
+// by r1 and r2 have the same slope. Like TwoSampleWelchTTest, it does
+// not assume the residuals of the two fits have the same variance,
+// and approximates the degrees of freedom of the difference by the
+// Welch-Satterthwaite equation.
+//
+// Returns ErrZeroVariance if both fits are exact.
+func RegressionSlopeTest(r1, r2 *LinearRegressionResult, alt LocationHypothesis) (*TTestResult, error) {
+	v1, v2 := r1.SlopeStdErr*r1.SlopeStdErr, r2.SlopeStdErr*r2.SlopeStdErr
+	if v1 == 0 && v2 == 0 {
+		return nil, ErrZeroVariance
+	}
+	dof := (v1 + v2) * (v1 + v2) / (v1*v1/r1.DoF() + v2*v2/r2.DoF())
+	t := (r1.Slope - r2.Slope) / math.Sqrt(v1+v2)
+	return newTTestResult(r1.N, r2.N, t, dof, alt), nil
+}
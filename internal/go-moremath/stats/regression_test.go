@@ -0,0 +1,46 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestLinearRegression(t *testing.T) {
+	xs := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	ys := []float64{2.1, 3.9, 6.2, 7.8, 10.1, 12.2, 13.8, 16.1}
+	r, err := LinearRegression(xs, ys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.N != 8 || !aeq(r.Slope, 1.9976190476190478) || !aeq(r.Intercept, 0.03571428571428292) ||
+		!aeq(r.SlopeStdErr, 0.027800444266884043) || !aeq(r.ResidualStdErr, 0.1801674705942149) ||
+		!aeq(r.R2, 0.9988392866011389) {
+		t.Errorf("LinearRegression = %+v", r)
+	}
+	if lo, hi := r.SlopeInterval(0.95); !aeq(lo, 1.9295938110753141) || !aeq(hi, 2.0656442841627816) {
+		t.Errorf("SlopeInterval(0.95) = [%v, %v], want [1.9295938, 2.0656443]", lo, hi)
+	}
+	if lo, hi := r.PredictInterval(10, 0.95); !aeq(lo, 19.60659763805607) || !aeq(hi, 20.41721188575345) {
+		t.Errorf("PredictInterval(10, 0.95) = [%v, %v], want [19.6065976, 20.4172119]", lo, hi)
+	}
+
+	r2, err := LinearRegression([]float64{1, 2, 3, 4, 5, 6}, []float64{1.0, 2.6, 3.1, 4.9, 5.2, 6.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := RegressionSlopeTest(r, r2, LocationDiffers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !aeq(res.T, 9.646134577776166) || !aeq(res.DoF, 4.797323363586287) || !aeq(res.P, 0.0002543820683376108) {
+		t.Errorf("RegressionSlopeTest = %+v, want T=9.6461346 DoF=4.7973234 P=0.00025438", res)
+	}
+
+	if _, err := LinearRegression([]float64{1, 2}, []float64{1, 2}); err != ErrSampleSize {
+		t.Errorf("two points: want ErrSampleSize, got %v", err)
+	}
+	if _, err := LinearRegression([]float64{1, 1, 1}, []float64{1, 2, 3}); err != ErrZeroVariance {
+		t.Errorf("constant x: want ErrZeroVariance, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// The robust scale estimators below are scaled by consistency
+// constants so that, for large samples from a normal distribution,
+// they estimate the standard deviation. Unlike the standard
+// deviation, each can tolerate up to half of the sample being
+// arbitrarily corrupted, and unlike the range, they do not grow with
+// the size of the sample.
+
+// MAD returns the median absolute deviation of the Sample from its
+// median, multiplied by 1.4826 to make it a consistent estimator of
+// the standard deviation of a normal distribution.
+//
+// If len(xs) == 0, returns NaN.
+func (s Sample) MAD() float64 {
+	if len(s.Xs) == 0 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted MAD.
+		panic("Weighted MAD not implemented")
+	}
+	median := s.Quantile(0.5, QuantileR7)
+	devs := make([]float64, len(s.Xs))
+	for i, x := range s.Xs {
+		devs[i] = math.Abs(x - median)
+	}
+	return 1.4826 * Sample{Xs: devs}.Quantile(0.5, QuantileR7)
+}
+
+// Qn returns the Rousseeuw-Croux Qn estimator of scale [1]: roughly
+// the first quartile of the distances between pairs of values. It
+// is more efficient than MAD for normal data and, unlike MAD, does
+// not assume the distribution is symmetric. It includes the
+// consistency constant for the normal distribution and the
+// finite-sample correction factors of Croux and Rousseeuw [2].
+//
+// If len(xs) < 2, returns NaN.
+//
+// [1] Rousseeuw, P. J. and Croux, C. (1993). "Alternatives to the
+// Median Absolute Deviation". Journal of the American Statistical
+// Association 88 (424): 1273-1283.
+//
+// [2] Croux, C. and Rousseeuw, P. J. (1992). "Time-Efficient
+// Algorithms for Two Highly Robust Estimators of Scale".
+// Computational Statistics 1: 411-428.
+func (s Sample) Qn() float64 {
+	n := len(s.Xs)
+	if n < 2 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted Qn.
+		panic("Weighted Qn not implemented")
+	}
+	// This uses the simple O(n² log n) algorithm, which is fine
+	// for the sample sizes of benchmarks.
+	diffs := make([]float64, 0, n*(n-1)/2)
+	for i, xi := range s.Xs {
+		for _, xj := range s.Xs[i+1:] {
+			diffs = append(diffs, math.Abs(xi-xj))
+		}
+	}
+	sort.Float64s(diffs)
+	h := n/2 + 1
+	k := h * (h - 1) / 2
+	qn := 2.21914 * diffs[k-1]
+
+	var dn float64
+	switch {
+	case n <= 9:
+		dn = []float64{0, 0, 0.399, 0.994, 0.512, 0.844, 0.611, 0.857, 0.669, 0.872}[n]
+	case n%2 == 1:
+		dn = float64(n) / (float64(n) + 1.4)
+	default:
+		dn = float64(n) / (float64(n) + 3.8)
+	}
+	return dn * qn
+}
+
+// Sn returns the Rousseeuw-Croux Sn estimator of scale [1]: the
+// median over the values of the median distance from that value to
+// the others. Like Qn, it does not assume the distribution is
+// symmetric. It includes the consistency constant for the normal
+// distribution and the finite-sample correction factors of Croux and
+// Rousseeuw [2].
+//
+// If len(xs) < 2, returns NaN.
+//
+// [1] Rousseeuw, P. J. and Croux, C. (1993). "Alternatives to the
+// Median Absolute Deviation". Journal of the American Statistical
+// Association 88 (424): 1273-1283.
+//
+// [2] Croux, C. and Rousseeuw, P. J. (1992). "Time-Efficient
+// Algorithms for Two Highly Robust Estimators of Scale".
+// Computational Statistics 1: 411-428.
+func (s Sample) Sn() float64 {
+	n := len(s.Xs)
+	if n < 2 {
+		return math.NaN()
+	}
+	if s.Weights != nil {
+		// TODO: Implement weighted Sn.
+		panic("Weighted Sn not implemented")
+	}
+	// The inner median is the high median and the outer median
+	// is the low median, as in the definition.
+	meds := make([]float64, n)
+	diffs := make([]float64, n)
+	for i, xi := range s.Xs {
+		for j, xj := range s.Xs {
+			diffs[j] = math.Abs(xi - xj)
+		}
+		sort.Float64s(diffs)
+		meds[i] = diffs[n/2]
+	}
+	sort.Float64s(meds)
+	sn := 1.1926 * meds[(n+1)/2-1]
+
+	var cn float64
+	switch {
+	case n <= 9:
+		cn = []float64{0, 0, 0.743, 1.851, 0.954, 1.351, 0.993, 1.198, 1.005, 1.131}[n]
+	case n%2 == 1:
+		cn = float64(n) / (float64(n) - 0.9)
+	default:
+		cn = 1
+	}
+	return cn * sn
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRobustScale(t *testing.T) {
+	for _, test := range []struct {
+		xs          []float64
+		mad, qn, sn float64
+	}{
+		{[]float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1}, 2.14977, 2.4121086956521745, 2.5044600000000004},
+		{[]float64{1, 2, 3, 4, 100}, 1.4826, 1.87295416, 3.2224052000000003},
+		{[]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 4.4478, 3.9371838709677416, 3.896613861386139},
+	} {
+		s := Sample{Xs: test.xs}
+		if got := s.MAD(); !aeq(test.mad, got) {
+			t.Errorf("MAD(%v) = %v, want %v", test.xs, got, test.mad)
+		}
+		if got := s.Qn(); !aeq(test.qn, got) {
+			t.Errorf("Qn(%v) = %v, want %v", test.xs, got, test.qn)
+		}
+		if got := s.Sn(); !aeq(test.sn, got) {
+			t.Errorf("Sn(%v) = %v, want %v", test.xs, got, test.sn)
+		}
+	}
+
+	// For large normal samples, all three estimate σ.
+	r := rand.New(rand.NewSource(1))
+	xs := make([]float64, 2000)
+	for i := range xs {
+		xs[i] = 10 + 2*r.NormFloat64()
+	}
+	s := Sample{Xs: xs}
+	for name, got := range map[string]float64{"MAD": s.MAD(), "Qn": s.Qn(), "Sn": s.Sn()} {
+		if math.Abs(got-2) > 0.1 {
+			t.Errorf("%s of normal sample with σ=2 = %v", name, got)
+		}
+	}
+
+	if got := (Sample{Xs: []float64{1}}).Qn(); !math.IsNaN(got) {
+		t.Errorf("Qn of one value = %v, want NaN", got)
+	}
+}
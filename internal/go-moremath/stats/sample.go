@@ -203,6 +203,29 @@ func (s Sample) Variance() float64 {
 	panic("Weighted Variance not implemented")
 }
 
+// Autocorrelation returns the sample autocorrelation of xs at the
+// given lag: the correlation between xs[i] and xs[i+lag], normalized
+// by the variance of all of xs. Values far from 0 indicate that the
+// values of xs depend on their order. It returns NaN if xs has no
+// variance or fewer than lag+1 values.
+func Autocorrelation(xs []float64, lag int) float64 {
+	if lag < 0 || len(xs) <= lag {
+		return math.NaN()
+	}
+	mean := Mean(xs)
+	num, denom := 0.0, 0.0
+	for i, x := range xs {
+		denom += (x - mean) * (x - mean)
+		if i+lag < len(xs) {
+			num += (x - mean) * (xs[i+lag] - mean)
+		}
+	}
+	if denom == 0 {
+		return math.NaN()
+	}
+	return num / denom
+}
+
 // StdDev returns the sample standard deviation of xs.
 func StdDev(xs []float64) float64 {
 	return math.Sqrt(Variance(xs))
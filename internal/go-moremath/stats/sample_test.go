@@ -4,7 +4,10 @@
 
 package stats
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestSamplePercentile(t *testing.T) {
 	s := Sample{Xs: []float64{15, 20, 35, 40, 50}}
@@ -19,3 +22,20 @@ func TestSamplePercentile(t *testing.T) {
 		2:   50,
 	})
 }
+
+func TestAutocorrelation(t *testing.T) {
+	check := func(xs []float64, lag int, want float64) {
+		t.Helper()
+		if got := Autocorrelation(xs, lag); !aeq(want, got) {
+			t.Errorf("Autocorrelation(%v, %d) = %v, want %v", xs, lag, got, want)
+		}
+	}
+	check([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 0, 1)
+	check([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 1, 0.625)
+	check([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 2, 0.27380952380952384)
+	check([]float64{1, -1, 1, -1, 1, -1}, 1, -0.8333333333333334)
+	check([]float64{3, 1, 4, 1, 5, 9, 2, 6}, 1, -0.17523640661938533)
+	if got := Autocorrelation([]float64{2, 2, 2}, 1); !math.IsNaN(got) {
+		t.Errorf("Autocorrelation of constant sample = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "math"
+
+// An AlphaSpending function gives the cumulative type I error rate
+// that a group sequential test may spend by information fraction t,
+// for a test with overall significance level alpha.
+//
+// A sequential test looks at the data repeatedly as it accumulates
+// and may stop at any look. Testing at level alpha at every look
+// inflates the overall type I error rate. Instead, the test at a look
+// with information fraction t (for example, the number of samples so
+// far divided by the maximum number of samples) should use the level
+// f(alpha, t) - f(alpha, tPrev), where tPrev is the information
+// fraction of the previous look. By the Bonferroni inequality, the
+// overall type I error rate is then at most alpha no matter when the
+// looks happen or how many there are.
+//
+// An AlphaSpending function must be non-decreasing in t with
+// f(alpha, 0) = 0 and f(alpha, 1) = alpha.
+type AlphaSpending func(alpha, t float64) float64
+
+// OBrienFlemingSpending is the Lan-DeMets alpha spending function
+// approximating O'Brien-Fleming boundaries. It spends very little
+// alpha at early looks, so a test that runs to completion has nearly
+// the power of a fixed-size test.
+//
+// Lan, K. K. Gordon; DeMets, David L. (1983). "Discrete Sequential
+// Boundaries for Clinical Trials". Biometrika 70 (3): 659-663.
+func OBrienFlemingSpending(alpha, t float64) float64 {
+	if t <= 0 {
+		return 0
+	} else if t >= 1 {
+		return alpha
+	}
+	z := StdNormal.InvCDF(1 - alpha/2)
+	return 2 * (1 - StdNormal.CDF(z/math.Sqrt(t)))
+}
+
+// PocockSpending is the Lan-DeMets alpha spending function
+// approximating Pocock boundaries. It spends alpha nearly evenly
+// across looks, so it is more likely to stop early but has less power
+// at the last look than OBrienFlemingSpending.
+func PocockSpending(alpha, t float64) float64 {
+	if t <= 0 {
+		return 0
+	} else if t >= 1 {
+		return alpha
+	}
+	return alpha * math.Log(1+(math.E-1)*t)
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestAlphaSpending(t *testing.T) {
+	testFunc(t, "OBrienFlemingSpending(0.05, %v)",
+		func(t float64) float64 { return OBrienFlemingSpending(0.05, t) },
+		map[float64]float64{
+			-1:   0,
+			0:    0,
+			0.25: 8.857543832130332e-05,
+			0.5:  0.005574596680784527,
+			0.75: 0.023625121317601305,
+			1:    0.05,
+			2:    0.05,
+		})
+	testFunc(t, "PocockSpending(0.05, %v)",
+		func(t float64) float64 { return PocockSpending(0.05, t) },
+		map[float64]float64{
+			0:    0,
+			0.25: 0.01786870097543942,
+			0.5:  0.031005725347913876,
+			0.75: 0.04139944696214349,
+			1:    0.05,
+		})
+}
@@ -6,6 +6,7 @@ package stats
 
 import (
 	"math"
+	"math/rand"
 
 	"rsc.io/benchstat/internal/go-moremath/mathx"
 )
@@ -37,6 +38,25 @@ func (t TDist) CDF(x float64) float64 {
 	}
 }
 
+// Rand draws from t using Bailey's polar method.
+//
+// Bailey, R. W. (1994). "Polar Generation of Random Variates with the
+// t-Distribution". Mathematics of Computation 62 (206): 779-781.
+func (t TDist) Rand(r *rand.Rand) float64 {
+	uniform := rand.Float64
+	if r != nil {
+		uniform = r.Float64
+	}
+	for {
+		u, v := 2*uniform()-1, 2*uniform()-1
+		w := u*u + v*v
+		if w >= 1 || w == 0 {
+			continue
+		}
+		return u * math.Sqrt(t.V*(math.Pow(w, -2/t.V)-1)/w)
+	}
+}
+
 func (t TDist) Bounds() (float64, float64) {
 	return -4, 4
 }
@@ -4,7 +4,10 @@
 
 package stats
 
-import "testing"
+import (
+	"math/rand"
+	"testing"
+)
 
 func TestT(t *testing.T) {
 	testFunc(t, "PDF(%v|v=1)", TDist{1}.PDF, map[float64]float64{
@@ -93,3 +96,27 @@ func TestT(t *testing.T) {
 		8:   0.99975354666971372,
 		9:   0.9998586600128780})
 }
+
+func TestTRand(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, v := range []float64{1, 4, 30} {
+		dist := TDist{v}
+		xs := make([]float64, 20000)
+		for i := range xs {
+			xs[i] = dist.Rand(r)
+		}
+		// Compare the empirical CDF with the distribution's.
+		for _, x := range []float64{-2, -1, 0, 0.5, 2} {
+			want := dist.CDF(x)
+			got := 0
+			for _, y := range xs {
+				if y <= x {
+					got++
+				}
+			}
+			if diff := float64(got)/float64(len(xs)) - want; diff < -0.01 || diff > 0.01 {
+				t.Errorf("TDist{%v}: fraction of draws <= %v is %v, want %v", v, x, float64(got)/float64(len(xs)), want)
+			}
+		}
+	}
+}
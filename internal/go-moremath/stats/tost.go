@@ -0,0 +1,151 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+// A TOSTResult is the result of a two one-sided tests (TOST)
+// equivalence test.
+type TOSTResult struct {
+	// N1 and N2 are the sizes of the input samples.
+	N1, N2 int
+
+	// Lower and Upper are the equivalence bounds. The null
+	// hypothesis is that the difference in the locations of the
+	// two samples (first minus second) is at most Lower or at
+	// least Upper. The alternative hypothesis is that it lies
+	// strictly between them, that is, that the samples are
+	// equivalent.
+	Lower, Upper float64
+
+	// PLower is the p-value of the one-sided test that the
+	// difference is greater than Lower, and PUpper is the
+	// p-value of the one-sided test that the difference is less
+	// than Upper.
+	PLower, PUpper float64
+
+	// P is the p-value of the equivalence test, which is the
+	// larger of PLower and PUpper.
+	P float64
+}
+
+func newTOSTResult(n1, n2 int, lower, upper, plower, pupper float64) *TOSTResult {
+	p := plower
+	if pupper > p {
+		p = pupper
+	}
+	return &TOSTResult{N1: n1, N2: n2, Lower: lower, Upper: upper,
+		PLower: plower, PUpper: pupper, P: p}
+}
+
+// shiftedSample is a TTestSample with its mean shifted by delta.
+type shiftedSample struct {
+	TTestSample
+	delta float64
+}
+
+func (s shiftedSample) Mean() float64 {
+	return s.TTestSample.Mean() + s.delta
+}
+
+// TwoSampleWelchTOST performs Schuirmann's two one-sided tests (TOST)
+// procedure for equivalence [1] using Welch's t-test. This tests the
+// null hypothesis that the difference of the means of x1 and x2
+// (μ1 - μ2) is outside (lower, upper) against the alternative
+// hypothesis that it lies within it.
+//
+// Failing to reject the null hypothesis of an ordinary t-test does
+// not show that two samples are equivalent; a TOST does. At
+// significance level α, this test rejects if and only if the
+// 1-2α confidence interval of μ1 - μ2 lies within (lower, upper).
+//
+// This can fail with the same errors as TwoSampleWelchTTest.
+//
+// [1] Schuirmann, Donald J. (1987). "A comparison of the two one-sided
+// tests procedure and the power approach for assessing the
+// equivalence of average bioavailability". Journal of
+// Pharmacokinetics and Biopharmaceutics 15 (6): 657-680.
+func TwoSampleWelchTOST(x1, x2 TTestSample, lower, upper float64) (*TOSTResult, error) {
+	tl, err := TwoSampleWelchTTest(x1, shiftedSample{x2, lower}, LocationGreater)
+	if err != nil {
+		return nil, err
+	}
+	tu, err := TwoSampleWelchTTest(x1, shiftedSample{x2, upper}, LocationLess)
+	if err != nil {
+		return nil, err
+	}
+	return newTOSTResult(tl.N1, tl.N2, lower, upper, tl.P, tu.P), nil
+}
+
+// MannWhitneyTOST performs a rank-based two one-sided tests (TOST)
+// procedure for equivalence using the Mann-Whitney U-test. This tests
+// the null hypothesis that x1 is shifted from x2 by at most lower or
+// at least upper against the alternative hypothesis that the shift
+// lies within (lower, upper). Like the U-test, this does not assume
+// the samples are normally distributed.
+//
+// This can fail with the same errors as MannWhitneyUTest.
+func MannWhitneyTOST(x1, x2 []float64, lower, upper float64) (*TOSTResult, error) {
+	shift := func(delta float64) []float64 {
+		s := make([]float64, len(x2))
+		for i, x := range x2 {
+			s[i] = x + delta
+		}
+		return s
+	}
+	ul, err := MannWhitneyUTest(x1, shift(lower), LocationGreater)
+	if err != nil {
+		return nil, err
+	}
+	uu, err := MannWhitneyUTest(x1, shift(upper), LocationLess)
+	if err != nil {
+		return nil, err
+	}
+	return newTOSTResult(ul.N1, ul.N2, lower, upper, ul.P, uu.P), nil
+}
+
+// PairedTOST performs the two one-sided tests (TOST) procedure for
+// equivalence using the paired t-test. This tests the null hypothesis
+// that the mean difference of the pairs (x1[i] - x2[i]) is outside
+// (lower, upper) against the alternative hypothesis that it lies
+// within it.
+//
+// This can fail with the same errors as PairedTTest.
+func PairedTOST(x1, x2 []float64, lower, upper float64) (*TOSTResult, error) {
+	tl, err := PairedTTest(x1, x2, lower, LocationGreater)
+	if err != nil {
+		return nil, err
+	}
+	tu, err := PairedTTest(x1, x2, upper, LocationLess)
+	if err != nil {
+		return nil, err
+	}
+	return newTOSTResult(tl.N1, tl.N2, lower, upper, tl.P, tu.P), nil
+}
+
+// WilcoxonSignedRankTOST performs a rank-based two one-sided tests
+// (TOST) procedure for equivalence of paired samples using the
+// Wilcoxon signed-rank test. This tests the null hypothesis that the
+// differences of the pairs are centered at or below lower or at or
+// above upper against the alternative hypothesis that their center
+// lies within (lower, upper).
+//
+// This can fail with the same errors as WilcoxonSignedRankTest.
+func WilcoxonSignedRankTOST(x1, x2 []float64, lower, upper float64) (*TOSTResult, error) {
+	shift := func(delta float64) []float64 {
+		s := make([]float64, len(x2))
+		for i, x := range x2 {
+			s[i] = x + delta
+		}
+		return s
+	}
+	wl, err := WilcoxonSignedRankTest(x1, shift(lower), LocationGreater)
+	if err != nil {
+		return nil, err
+	}
+	wu, err := WilcoxonSignedRankTest(x1, shift(upper), LocationLess)
+	if err != nil {
+		return nil, err
+	}
+	return newTOSTResult(len(x1), len(x2), lower, upper, wl.P, wu.P), nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestTOST(t *testing.T) {
+	check := func(name string, got *TOSTResult, err error, plower, pupper float64) {
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+			return
+		}
+		p := plower
+		if pupper > p {
+			p = pupper
+		}
+		if !aeq(plower, got.PLower) || !aeq(pupper, got.PUpper) || !aeq(p, got.P) {
+			t.Errorf("%s: want PLower=%v PUpper=%v P=%v, got %+v", name, plower, pupper, p, got)
+		}
+	}
+	sub := func(xs []float64, d float64) []float64 {
+		ys := make([]float64, len(xs))
+		for i, x := range xs {
+			ys[i] = x + d
+		}
+		return ys
+	}
+
+	s1 := []float64{2, 1, 3, 4}
+	s2 := []float64{6, 5, 7, 9}
+	for _, bounds := range [][2]float64{{-5, -3}, {-1, 1}, {-10, 10}} {
+		lower, upper := bounds[0], bounds[1]
+
+		// TOST is equivalent to two t-tests against
+		// shifted samples.
+		tl, _ := TwoSampleWelchTTest(Sample{Xs: s1}, Sample{Xs: sub(s2, lower)}, LocationGreater)
+		tu, _ := TwoSampleWelchTTest(Sample{Xs: s1}, Sample{Xs: sub(s2, upper)}, LocationLess)
+		got, err := TwoSampleWelchTOST(Sample{Xs: s1}, Sample{Xs: s2}, lower, upper)
+		check("TwoSampleWelchTOST", got, err, tl.P, tu.P)
+
+		ul, _ := MannWhitneyUTest(s1, sub(s2, lower), LocationGreater)
+		uu, _ := MannWhitneyUTest(s1, sub(s2, upper), LocationLess)
+		got, err = MannWhitneyTOST(s1, s2, lower, upper)
+		check("MannWhitneyTOST", got, err, ul.P, uu.P)
+
+		pl, _ := PairedTTest(s1, s2, lower, LocationGreater)
+		pu, _ := PairedTTest(s1, s2, upper, LocationLess)
+		got, err = PairedTOST(s1, s2, lower, upper)
+		check("PairedTOST", got, err, pl.P, pu.P)
+
+		wl, _ := WilcoxonSignedRankTest(s1, sub(s2, lower), LocationGreater)
+		wu, _ := WilcoxonSignedRankTest(s1, sub(s2, upper), LocationLess)
+		got, err = WilcoxonSignedRankTOST(s1, s2, lower, upper)
+		check("WilcoxonSignedRankTOST", got, err, wl.P, wu.P)
+	}
+
+	// Samples whose difference is well within the bounds are
+	// equivalent; samples whose difference is near a bound are
+	// not.
+	l1 := make([]float64, 50)
+	l2 := make([]float64, 50)
+	for i := range l1 {
+		l1[i] = 100 + float64(i%10)
+		l2[i] = 100.5 + float64((i+3)%10)
+	}
+	if r, _ := TwoSampleWelchTOST(Sample{Xs: l1}, Sample{Xs: l2}, -2, 2); r.P > 0.01 {
+		t.Errorf("TwoSampleWelchTOST of equivalent samples: P=%v, want < 0.01", r.P)
+	}
+	if r, _ := MannWhitneyTOST(l1, l2, -2, 2); r.P > 0.01 {
+		t.Errorf("MannWhitneyTOST of equivalent samples: P=%v, want < 0.01", r.P)
+	}
+	// Pairs that differ by about 0.5 are equivalent within ±2.
+	p2 := make([]float64, 50)
+	for i := range p2 {
+		p2[i] = l1[i] + 0.5 + 0.1*float64(i%3-1)
+	}
+	if r, _ := PairedTOST(l1, p2, -2, 2); r.P > 0.01 {
+		t.Errorf("PairedTOST of equivalent samples: P=%v, want < 0.01", r.P)
+	}
+	if r, _ := WilcoxonSignedRankTOST(l1, p2, -2, 2); r.P > 0.01 {
+		t.Errorf("WilcoxonSignedRankTOST of equivalent samples: P=%v, want < 0.01", r.P)
+	}
+	if r, _ := TwoSampleWelchTOST(Sample{Xs: l1}, Sample{Xs: l2}, -0.5, 2); r.P < 0.4 {
+		t.Errorf("TwoSampleWelchTOST of samples at the bound: P=%v, want ≈0.5", r.P)
+	}
+	if r, err := TwoSampleWelchTOST(Sample{Xs: []float64{1, 1}}, Sample{Xs: []float64{1, 1}}, -1, 1); err != ErrZeroVariance {
+		t.Errorf("want ErrZeroVariance, got %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// trimCount returns the number of values to trim from each end of a
+// sample of size n when trimming the given fraction of it.
+func trimCount(n int, trim float64) int {
+	return int(math.Floor(trim * float64(n)))
+}
+
+// TrimmedMean returns the mean of the Sample after discarding the
+// fraction trim (between 0 and 0.5) of the values from each end. A
+// 20% trimmed mean is nearly as efficient as the mean for normal data
+// and much less affected by outliers and skew.
+//
+// If len(xs) == 0 or trim leaves no values, returns NaN.
+func (s Sample) TrimmedMean(trim float64) float64 {
+	if s.Weights != nil {
+		// TODO: Implement weighted trimmed mean.
+		panic("Weighted TrimmedMean not implemented")
+	}
+	n := len(s.Xs)
+	g := trimCount(n, trim)
+	if n-2*g <= 0 {
+		return math.NaN()
+	}
+	if !s.Sorted {
+		s = *s.Copy().Sort()
+	}
+	return Mean(s.Xs[g : n-g])
+}
+
+// winsorize returns a sorted copy of xs with the g smallest values
+// replaced by the next smallest and the g largest replaced by the
+// next largest.
+func winsorize(xs []float64, g int) []float64 {
+	w := append([]float64(nil), xs...)
+	sort.Float64s(w)
+	n := len(w)
+	for i := 0; i < g; i++ {
+		w[i], w[n-1-i] = w[g], w[n-1-g]
+	}
+	return w
+}
+
+// WinsorizedMean returns the mean of the Sample after replacing the
+// fraction trim (between 0 and 0.5) of the values at each end with
+// the most extreme value that remains.
+//
+// If len(xs) == 0 or trim leaves no values, returns NaN.
+func (s Sample) WinsorizedMean(trim float64) float64 {
+	if s.Weights != nil {
+		// TODO: Implement weighted winsorized mean.
+		panic("Weighted WinsorizedMean not implemented")
+	}
+	n := len(s.Xs)
+	g := trimCount(n, trim)
+	if n-2*g <= 0 {
+		return math.NaN()
+	}
+	return Mean(winsorize(s.Xs, g))
+}
+
+// WinsorizedVariance returns the sample variance of the Sample after
+// winsorizing it as for WinsorizedMean. This is the variance that
+// enters the standard error of the trimmed mean.
+//
+// If len(xs) < 2, returns NaN.
+func (s Sample) WinsorizedVariance(trim float64) float64 {
+	if s.Weights != nil {
+		// TODO: Implement weighted winsorized variance.
+		panic("Weighted WinsorizedVariance not implemented")
+	}
+	n := len(s.Xs)
+	g := trimCount(n, trim)
+	if n < 2 || n-2*g <= 0 {
+		return math.NaN()
+	}
+	return Variance(winsorize(s.Xs, g))
+}
+
+// YuenTTest performs Yuen's two-sample trimmed-mean t-test [1] on
+// samples x1 and x2, trimming the fraction trim (e.g., 0.2) of each
+// sample from each end. This is a test of the null hypothesis that
+// x1 and x2 are drawn from populations with equal trimmed means. Like
+// TwoSampleWelchTTest, it does not assume the distributions have
+// equal variance, but it is far less sensitive to outliers and
+// heavy tails. With trim = 0, it is Welch's t-test.
+//
+// The test requires at least two values to remain in each sample
+// after trimming.
+//
+// [1] Yuen, K. K. (1974). "The two-sample trimmed t for unequal
+// population variances". Biometrika 61 (1): 165-170.
+func YuenTTest(x1, x2 Sample, trim float64, alt LocationHypothesis) (*TTestResult, error) {
+	if x1.Weights != nil || x2.Weights != nil {
+		// TODO: Implement weighted Yuen's t-test.
+		panic("Weighted YuenTTest not implemented")
+	}
+	n1, n2 := len(x1.Xs), len(x2.Xs)
+	h1, h2 := n1-2*trimCount(n1, trim), n2-2*trimCount(n2, trim)
+	if h1 < 2 || h2 < 2 {
+		return nil, ErrSampleSize
+	}
+
+	// d is the squared standard error of each trimmed mean.
+	d1 := float64(n1-1) * x1.WinsorizedVariance(trim) / float64(h1*(h1-1))
+	d2 := float64(n2-1) * x2.WinsorizedVariance(trim) / float64(h2*(h2-1))
+	if d1 == 0 && d2 == 0 {
+		return nil, ErrZeroVariance
+	}
+
+	dof := (d1 + d2) * (d1 + d2) / (d1*d1/float64(h1-1) + d2*d2/float64(h2-1))
+	t := (x1.TrimmedMean(trim) - x2.TrimmedMean(trim)) / math.Sqrt(d1+d2)
+	return newTTestResult(n1, n2, t, dof, alt), nil
+}
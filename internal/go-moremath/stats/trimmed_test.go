@@ -0,0 +1,54 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestTrimmedMean(t *testing.T) {
+	s := Sample{Xs: []float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1, 40}}
+	if got, want := s.TrimmedMean(0.2), 4.728571428571429; !aeq(want, got) {
+		t.Errorf("TrimmedMean(0.2) = %v, want %v", got, want)
+	}
+	if got, want := s.WinsorizedMean(0.2), 4.9363636363636365; !aeq(want, got) {
+		t.Errorf("WinsorizedMean(0.2) = %v, want %v", got, want)
+	}
+	if got, want := s.WinsorizedVariance(0.2), 4.224545454545455; !aeq(want, got) {
+		t.Errorf("WinsorizedVariance(0.2) = %v, want %v", got, want)
+	}
+	if got, want := s.TrimmedMean(0), s.Mean(); !aeq(want, got) {
+		t.Errorf("TrimmedMean(0) = %v, want %v", got, want)
+	}
+}
+
+func TestYuenTTest(t *testing.T) {
+	s1 := Sample{Xs: []float64{2.1, 3.5, 1.2, 7.7, 4.4, 5.0, 6.3, 2.9, 3.3, 8.1, 40}}
+	s2 := Sample{Xs: []float64{5.5, 6.1, 7.0, 5.9, 6.6, 8.2, 7.3, 6.4, 30}}
+	for _, test := range []struct{ trim, t, dof, p float64 }{
+		{0.2, -1.9094669064646042, 7.80424137852449, 0.0935256463215346},
+		{0.1, -1.6024211286688366, 9.761868052747788, 0.14088855719778937},
+	} {
+		r, err := YuenTTest(s1, s2, test.trim, LocationDiffers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aeq(test.t, r.T) || !aeq(test.dof, r.DoF) || !aeq(test.p, r.P) {
+			t.Errorf("YuenTTest(trim=%v) = t=%v dof=%v p=%v, want t=%v dof=%v p=%v", test.trim, r.T, r.DoF, r.P, test.t, test.dof, test.p)
+		}
+	}
+
+	// With no trimming, this is Welch's t-test.
+	r, _ := YuenTTest(s1, s2, 0, LocationDiffers)
+	w, _ := TwoSampleWelchTTest(s1, s2, LocationDiffers)
+	if !aeq(r.T, w.T) || !aeq(r.DoF, w.DoF) || !aeq(r.P, w.P) {
+		t.Errorf("YuenTTest(trim=0) = %+v, want %+v", r, w)
+	}
+
+	if _, err := YuenTTest(Sample{Xs: []float64{1, 2, 3, 4, 5}}, s2, 0.4, LocationDiffers); err != ErrSampleSize {
+		t.Errorf("YuenTTest with 1 value left: err = %v, want ErrSampleSize", err)
+	}
+	if _, err := YuenTTest(Sample{Xs: []float64{1, 2, 2, 2, 3}}, Sample{Xs: []float64{0, 4, 4, 4, 5}}, 0.2, LocationDiffers); err != ErrZeroVariance {
+		t.Errorf("YuenTTest of constant trimmed samples: err = %v, want ErrZeroVariance", err)
+	}
+}
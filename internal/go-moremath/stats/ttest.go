@@ -100,6 +100,29 @@ func TwoSampleWelchTTest(x1, x2 TTestSample, alt LocationHypothesis) (*TTestResu
 	return newTTestResult(int(n1), int(n2), t, dof, alt), nil
 }
 
+// TwoSampleWelchTTestInterval returns a confidence interval at the
+// given confidence level (e.g., 0.95) for the difference between the
+// means of the populations x1 and x2 were drawn from, μ1 - μ2. This is
+// the set of differences that TwoSampleWelchTTest would not reject
+// at significance level 1 - confidence if x1 were shifted by them.
+func TwoSampleWelchTTestInterval(x1, x2 TTestSample, confidence float64) (lo, hi float64, err error) {
+	n1, n2 := x1.Weight(), x2.Weight()
+	if n1 <= 1 || n2 <= 1 {
+		return 0, 0, ErrSampleSize
+	}
+	v1, v2 := x1.Variance(), x2.Variance()
+	if v1 == 0 && v2 == 0 {
+		return 0, 0, ErrZeroVariance
+	}
+
+	dof := math.Pow(v1/n1+v2/n2, 2) /
+		(math.Pow(v1/n1, 2)/(n1-1) + math.Pow(v2/n2, 2)/(n2-1))
+	s := math.Sqrt(v1/n1 + v2/n2)
+	t := InvCDF(TDist{dof})(1 - (1-confidence)/2)
+	d := x1.Mean() - x2.Mean()
+	return d - t*s, d + t*s, nil
+}
+
 // PairedTTest performs a two-sample paired t-test on samples x1 and
 // x2. If μ0 is non-zero, this tests if the average of the difference
 // is significantly different from μ0. If x1 and x2 are identical,
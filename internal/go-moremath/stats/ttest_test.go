@@ -69,3 +69,35 @@ func TestTTest(t *testing.T) {
 	}, 4, 0, 0, 3,
 		0.5, 1, 0.5)
 }
+
+func TestTwoSampleWelchTTestInterval(t *testing.T) {
+	s1 := Sample{Xs: []float64{2, 1, 3, 4}}
+	s2 := Sample{Xs: []float64{6, 5, 7, 9}}
+	lo, hi, err := TwoSampleWelchTTestInterval(s1, s2, 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := s1.Mean() - s2.Mean(); !(lo < d && d < hi) || !aeq(d-lo, hi-d) {
+		t.Errorf("interval [%v, %v] not centered on %v", lo, hi, d)
+	}
+	// Shifting s1 by an endpoint puts the test right at the
+	// significance level.
+	for _, shift := range []float64{lo, hi} {
+		xs := make([]float64, len(s1.Xs))
+		for i, x := range s1.Xs {
+			xs[i] = x - shift
+		}
+		r, err := TwoSampleWelchTTest(Sample{Xs: xs}, s2, LocationDiffers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.P < 0.05-1e-6 || r.P > 0.05+1e-6 {
+			t.Errorf("shifted by %v: p = %v, want 0.05", shift, r.P)
+		}
+	}
+
+	c := Sample{Xs: []float64{1, 1}}
+	if _, _, err := TwoSampleWelchTTestInterval(c, c, 0.95); err != ErrZeroVariance {
+		t.Errorf("want ErrZeroVariance, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"rsc.io/benchstat/internal/go-moremath/mathx"
+)
+
+// A WilcoxonSignedRankTestResult is the result of a Wilcoxon
+// signed-rank test.
+type WilcoxonSignedRankTestResult struct {
+	// N is the number of pairs with a non-zero difference. Pairs
+	// with a zero difference are discarded.
+	N int
+
+	// W is the Wilcoxon signed-rank statistic W+: the sum of the
+	// ranks of the absolute differences x1[i] - x2[i] that are
+	// positive, where tied absolute differences share their
+	// average rank. W is in the range [0, N(N+1)/2].
+	W float64
+
+	// AltHypothesis specifies the alternative hypothesis tested
+	// by this test against the null hypothesis that the
+	// differences are symmetric about zero.
+	AltHypothesis LocationHypothesis
+
+	// P is the p-value of the Wilcoxon signed-rank test for the
+	// given null hypothesis.
+	P float64
+}
+
+// WilcoxonExactLimit gives the largest number of non-zero differences
+// for which the exact distribution of W will be used for the Wilcoxon
+// signed-rank test.
+//
+// Computing the exact distribution takes O(N³) time, with or without
+// ties, and it is close to normal well before this limit.
+var WilcoxonExactLimit = 50
+
+// WilcoxonSignedRankTest performs a Wilcoxon signed-rank test [1] of
+// the null hypothesis that the differences between paired samples x1
+// and x2 are symmetric about zero against the alternative hypothesis
+// that x1 tends to be less than, greater than, or different from x2.
+//
+// This is the paired counterpart of the Mann-Whitney U-test: like
+// PairedTTest, it removes the variation shared by each pair, but it
+// does not assume the differences are normally distributed.
+//
+// Pairs with a zero difference carry no information about the
+// direction of the difference, so they are discarded before ranking,
+// as in Wilcoxon's original procedure.
+//
+// This uses the exact distribution of W (see WDist), including in
+// the presence of ties, for up to WilcoxonExactLimit non-zero
+// differences. Otherwise, it uses a normal approximation with both
+// the tie correction and the continuity correction.
+//
+// This can fail with ErrMismatchedSamples if x1 and x2 have different
+// lengths, ErrSampleSize if they are empty, or ErrSamplesEqual if
+// every difference is zero.
+//
+// [1] Wilcoxon, Frank (1945). "Individual comparisons by ranking
+// methods". Biometrics Bulletin 1 (6): 80-83.
+func WilcoxonSignedRankTest(x1, x2 []float64, alt LocationHypothesis) (*WilcoxonSignedRankTestResult, error) {
+	if len(x1) != len(x2) {
+		return nil, ErrMismatchedSamples
+	}
+	if len(x1) == 0 {
+		return nil, ErrSampleSize
+	}
+
+	// Compute the non-zero differences, sorted by magnitude.
+	var diffs []float64
+	for i := range x1 {
+		if d := x1[i] - x2[i]; d != 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	n := len(diffs)
+	if n == 0 {
+		return nil, ErrSamplesEqual
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return math.Abs(diffs[i]) < math.Abs(diffs[j])
+	})
+
+	// Compute W+ and the tie vector T.
+	W := 0.0
+	T := []int{}
+	for i := 0; i < n; {
+		rank1, v := i+1, math.Abs(diffs[i])
+		npos := 0
+		for ; i < n && math.Abs(diffs[i]) == v; i++ {
+			if diffs[i] > 0 {
+				npos++
+			}
+		}
+		rank := float64(i+rank1) / 2
+		W += rank * float64(npos)
+		T = append(T, i-rank1+1)
+	}
+	Wmax := float64(n*(n+1)) / 2
+
+	var p float64
+	if n <= WilcoxonExactLimit {
+		// Use the exact distribution. W is a multiple of 0.5.
+		dist := WDist{N: n, T: T}
+		switch alt {
+		case LocationLess:
+			p = dist.CDF(W)
+		case LocationGreater:
+			// By symmetry, P(W >= w) = P(W <= Wmax - w).
+			p = dist.CDF(Wmax - W)
+		case LocationDiffers:
+			p = 2 * dist.CDF(math.Min(W, Wmax-W))
+		}
+	} else {
+		// Use normal approximation (with tie and continuity
+		// correction).
+		μ := Wmax / 2
+		σ := math.Sqrt(float64(n*(n+1)*(2*n+1))/24 - tieCorrection(T)/48)
+		if σ == 0 {
+			return nil, ErrSamplesEqual
+		}
+		numer := W - μ
+		switch alt {
+		case LocationDiffers:
+			numer -= mathx.Sign(numer) * 0.5
+		case LocationLess:
+			numer += 0.5
+		case LocationGreater:
+			numer -= 0.5
+		}
+		z := numer / σ
+		switch alt {
+		case LocationDiffers:
+			p = 2 * math.Min(StdNormal.CDF(z), 1-StdNormal.CDF(z))
+		case LocationLess:
+			p = StdNormal.CDF(z)
+		case LocationGreater:
+			p = 1 - StdNormal.CDF(z)
+		}
+	}
+	if p > 1 {
+		p = 1
+	}
+
+	return &WilcoxonSignedRankTestResult{N: n, W: W, AltHypothesis: alt, P: p}, nil
+}
+
+// A WDist is the discrete probability distribution of the Wilcoxon
+// signed-rank statistic W+ for N non-zero paired differences under
+// the null hypothesis that the differences are symmetric about zero.
+//
+// Under the null hypothesis, each of the 2^N ways of assigning signs
+// to the ranks of the absolute differences is equally likely, so the
+// distribution is that of the sum of a random subset of the ranks.
+// With ties, tied differences share their average rank and the
+// distribution is computed over these midranks, so W+ takes values
+// that are multiples of 0.5.
+type WDist struct {
+	N int
+
+	// T is the count of the number of ties at each rank of the
+	// absolute differences. T may be nil, in which case it is
+	// assumed there are no ties (which is equivalent to an N
+	// slice of 1s). It must be the case that Sum(T) == N.
+	T []int
+}
+
+// counts returns the number of sign assignments for which 2W+ equals
+// each value from 0 to N(N+1).
+func (d WDist) counts() []float64 {
+	// This is a dynamic programming computation of the number of
+	// subsets of the doubled midranks with each sum. The doubled
+	// midranks are integers, even with ties.
+	var ranks []int // doubled midranks
+	if d.T == nil {
+		for r := 1; r <= d.N; r++ {
+			ranks = append(ranks, 2*r)
+		}
+	} else {
+		r := 1
+		for _, t := range d.T {
+			// The midrank of ranks r..r+t-1.
+			for i := 0; i < t; i++ {
+				ranks = append(ranks, 2*r+t-1)
+			}
+			r += t
+		}
+	}
+	max := d.N * (d.N + 1)
+	counts := make([]float64, max+1)
+	counts[0] = 1
+	sum := 0
+	for _, rank := range ranks {
+		sum += rank
+		for w := sum; w >= rank; w-- {
+			counts[w] += counts[w-rank]
+		}
+	}
+	return counts
+}
+
+func (d WDist) PMF(W float64) float64 {
+	twoW := int(math.Floor(2 * W))
+	if twoW < 0 || twoW > d.N*(d.N+1) {
+		return 0
+	}
+	return d.counts()[twoW] / math.Ldexp(1, d.N)
+}
+
+func (d WDist) CDF(W float64) float64 {
+	twoW := int(math.Floor(2 * W))
+	if twoW < 0 {
+		return 0
+	} else if twoW >= d.N*(d.N+1) {
+		return 1
+	}
+	sum := 0.0
+	for _, c := range d.counts()[:twoW+1] {
+		sum += c
+	}
+	return sum / math.Ldexp(1, d.N)
+}
+
+func (d WDist) Step() float64 {
+	return 0.5
+}
+
+func (d WDist) Bounds() (float64, float64) {
+	return 0, float64(d.N*(d.N+1)) / 2
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import "testing"
+
+func TestWilcoxonSignedRankTest(t *testing.T) {
+	check := func(want, got *WilcoxonSignedRankTestResult) {
+		if want.N != got.N || !aeq(want.W, got.W) ||
+			want.AltHypothesis != got.AltHypothesis ||
+			!aeq(want.P, got.P) {
+			t.Errorf("want %+v, got %+v", want, got)
+		}
+	}
+	check3 := func(x1, x2 []float64, N int, W float64, pless, pdiff, pgreater float64) {
+		want := &WilcoxonSignedRankTestResult{N: N, W: W}
+
+		want.AltHypothesis = LocationLess
+		want.P = pless
+		got, _ := WilcoxonSignedRankTest(x1, x2, want.AltHypothesis)
+		check(want, got)
+
+		want.AltHypothesis = LocationDiffers
+		want.P = pdiff
+		got, _ = WilcoxonSignedRankTest(x1, x2, want.AltHypothesis)
+		check(want, got)
+
+		want.AltHypothesis = LocationGreater
+		want.P = pgreater
+		got, _ = WilcoxonSignedRankTest(x1, x2, want.AltHypothesis)
+		check(want, got)
+	}
+
+	// Hollander & Wolfe depression data, as in R's wilcox.test
+	// example:
+	// x <- c(1.83, 0.50, 1.62, 2.48, 1.68, 1.88, 1.55, 3.06, 1.30)
+	// y <- c(0.878, 0.647, 0.598, 2.05, 1.06, 1.29, 1.06, 3.14, 1.29)
+	x := []float64{1.83, 0.50, 1.62, 2.48, 1.68, 1.88, 1.55, 3.06, 1.30}
+	y := []float64{0.878, 0.647, 0.598, 2.05, 1.06, 1.29, 1.06, 3.14, 1.29}
+	check3(x, y, 9, 40, 0.986328125, 0.0390625, 0.01953125)
+	check3(y, x, 9, 5, 0.01953125, 0.0390625, 0.986328125)
+
+	// Small sample, ties and zeros. The zero difference is
+	// discarded and the exact distribution accounts for ties.
+	s1 := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	s2 := []float64{0, 0, 1, 2, 3, 3, 9, 8}
+	check3(s1, s2, 7, 24, 0.984375, 0.109375, 0.0546875)
+
+	// Large sample, ties.
+	l1 := make([]float64, 60)
+	l2 := make([]float64, 60)
+	for i := range l1 {
+		l1[i] = float64(i)
+		l2[i] = float64(i + (i*7)%11 - 4)
+	}
+	check3(l1, l2, 55, 495, 0.01046056147115415, 0.0209211229423083, 0.9897703938899657)
+
+	if r, err := WilcoxonSignedRankTest(s1, s1, LocationDiffers); err != ErrSamplesEqual {
+		t.Errorf("want ErrSamplesEqual, got %+v, %+v", r, err)
+	}
+	if r, err := WilcoxonSignedRankTest(s1, x, LocationDiffers); err != ErrMismatchedSamples {
+		t.Errorf("want ErrMismatchedSamples, got %+v, %+v", r, err)
+	}
+}
+
+// W distribution for N=1 to 8 up to W=8.
+var wdist = [][]float64{
+	//   N=1        2         3         4         5         6         7         8
+	{0.500000, 0.250000, 0.125000, 0.062500, 0.031250, 0.015625, 0.007812, 0.003906}, // W=0
+	{1.000000, 0.500000, 0.250000, 0.125000, 0.062500, 0.031250, 0.015625, 0.007812}, // W=1
+	{1.000000, 0.750000, 0.375000, 0.187500, 0.093750, 0.046875, 0.023438, 0.011719}, // W=2
+	{1.000000, 1.000000, 0.625000, 0.312500, 0.156250, 0.078125, 0.039062, 0.019531}, // W=3
+	{1.000000, 1.000000, 0.750000, 0.437500, 0.218750, 0.109375, 0.054688, 0.027344}, // W=4
+	{1.000000, 1.000000, 0.875000, 0.562500, 0.312500, 0.156250, 0.078125, 0.039062}, // W=5
+	{1.000000, 1.000000, 1.000000, 0.687500, 0.406250, 0.218750, 0.109375, 0.054688}, // W=6
+	{1.000000, 1.000000, 1.000000, 0.812500, 0.500000, 0.281250, 0.148438, 0.074219}, // W=7
+	{1.000000, 1.000000, 1.000000, 0.875000, 0.593750, 0.343750, 0.187500, 0.097656}, // W=8
+}
+
+func TestWDist(t *testing.T) {
+	makeTable := func() [][]float64 {
+		table := make([][]float64, len(wdist))
+		for W := range table {
+			table[W] = make([]float64, len(wdist[0]))
+			for n := range table[W] {
+				table[W][n] = WDist{N: n + 1}.CDF(float64(W))
+			}
+		}
+		return table
+	}
+	if got := makeTable(); !aeqTable(wdist, got) {
+		t.Errorf("want W CDF table %v, got %v", wdist, got)
+	}
+
+	// All ties as 1s is the same as no ties.
+	for n := 1; n <= 8; n++ {
+		t1 := make([]int, n)
+		for i := range t1 {
+			t1[i] = 1
+		}
+		for W := 0.0; W <= 8; W++ {
+			want, got := WDist{N: n}.CDF(W), WDist{N: n, T: t1}.CDF(W)
+			if !aeq(want, got) {
+				t.Errorf("WDist{N: %d, T: 1s}.CDF(%v) = %v, want %v", n, W, got, want)
+			}
+		}
+	}
+
+	// Ties at ranks {1,2}, {3}, {4,5,6}, so the midranks are 1.5,
+	// 1.5, 3, 5, 5, 5. These were computed by enumerating all
+	// sign assignments.
+	d := WDist{N: 6, T: []int{2, 1, 3}}
+	testFunc(t, "WDist{6, [2 1 3]}.CDF", d.CDF, map[float64]float64{
+		-1:   0,
+		0:    0.015625,
+		1.5:  0.046875,
+		3:    0.078125,
+		4.5:  0.109375,
+		6.5:  0.265625,
+		8:    0.359375,
+		10.5: 0.5,
+		21:   1,
+	})
+	testFunc(t, "WDist{6, [2 1 3]}.PMF", d.PMF, map[float64]float64{
+		0:    0.015625,
+		0.5:  0,
+		1.5:  0.03125,
+		6.5:  0.09375,
+		10.5: 0,
+		21:   0.015625,
+	})
+	testDiscreteCDF(t, "WDist{6, [2 1 3]}", d)
+	testDiscreteCDF(t, "WDist{8}", WDist{N: 8})
+}
@@ -9,12 +9,16 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"html"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -24,18 +28,71 @@ import (
 
 func usage() {
 	fmt.Fprintf(os.Stderr, "usage: benchstat [options] old.txt [new.txt] [more.txt ...]\n")
+	fmt.Fprintf(os.Stderr, "       benchstat -store file [options] query [query ...]\n")
+	fmt.Fprintf(os.Stderr, "       benchstat save -store file [-label key=value ...] file ...\n")
+	fmt.Fprintf(os.Stderr, "       benchstat run [options] old-rev [new-rev]\n")
 	fmt.Fprintf(os.Stderr, "options:\n")
 	flag.PrintDefaults()
 	os.Exit(2)
 }
 
 var (
-	flagDeltaTest = flag.String("delta-test", "utest", "significance `test` to apply to delta: utest, ttest, or none")
+	flagDeltaTest = flag.String("delta-test", "utest", "significance `test` to apply to delta: utest, ttest, yuen, ks, ad, bayes, or none")
 	flagAlpha     = flag.Float64("alpha", 0.05, "consider change significant if p < `α`")
 	flagGeomean   = flag.Bool("geomean", false, "print the geometric mean of each file")
 	flagHTML      = flag.Bool("html", false, "print results as an HTML table")
+	flagTrend     = flag.Bool("trend", false, "treat the files as a history and report where each benchmark changed")
+	flagTrendSort = flag.String("trend-sort", "", "in -trend mode, order files by configuration `label` (e.g., date) instead of argument order")
+	flagStore     = flag.String("store", "", "read results matching the query arguments from the result store `file`")
+	flagPower     = flag.Float64("power", 0, "report the minimum detectable effect at statistical `power` (e.g., 0.8) and the samples needed to detect -power-effect")
+	flagEffect    = flag.Float64("power-effect", 2, "target effect in `percent` for -power")
+	flagPaired    = flag.Bool("paired", false, "pair the samples of old and new by run order (or by -pair-label) and use paired tests")
+	flagPairLabel = flag.String("pair-label", "", "in -paired mode, pair samples by the configuration `label` (e.g., run) preceding them")
+	flagMargin    = flag.Float64("margin", 0, "test whether changes are within ±`percent` and report them as equivalent, different, or inconclusive")
+	flagMinEffect = flag.Float64("min-effect", 0, "do not report significant changes whose effect size (|Hedges' g| for ttest, |Cliff's δ| otherwise) is below `size`")
+	flagLog       = flag.Bool("log", false, "analyze the logarithms of the values: report geometric means, apply the t-test to logarithms, and give confidence intervals for ratios")
+	flagQuantiles = quantileVar("quantiles", "compare the `percentiles` (e.g., 50,90,99) of the samples instead of their means")
+	flagNoise     = flag.Bool("noise", false, "report how noisy each benchmark is, from the most to the least stable")
+	flagTrim      = flag.Float64("trim", 20, "in -delta-test yuen, trim `percent` of the values from each end of the samples")
+	flagScaling   = flag.String("scaling", "", "report how each benchmark scales with the numeric sub-benchmark `key` (e.g., n for Sort/n=100)")
+	flagProcs     = flag.Bool("procs", false, "report the speedup and parallel efficiency of benchmarks run at several GOMAXPROCS settings (-N name suffixes)")
+	flagWeighted  = flag.Bool("weighted", false, "weight each sample in the mean by its iteration count (N), giving the mean over all iterations; significance tests still weight samples equally")
+	flagSpread    = flag.String("spread", "range", "base the ± column on `estimator`: range (the largest deviation from the mean), mad, qn, or sn")
 )
 
+// spreads maps each -spread estimator to a function returning the
+// spread of a sample, or nil for the range. The robust estimators
+// estimate the standard deviation, so unlike the range they do not
+// grow with the number of samples.
+var spreads = map[string]func(stats.Sample) float64{
+	"range": nil,
+	"mad":   stats.Sample.MAD,
+	"qn":    stats.Sample.Qn,
+	"sn":    stats.Sample.Sn,
+}
+
+// pairedDeltaTests maps each kind of delta test to its paired
+// counterpart.
+var pairedDeltaTests = map[string]func(old, new *Benchstat) (float64, error){
+	"none":  notest,
+	"utest": signedRankTest,
+	"ttest": pairedTTest,
+}
+
+// lookupDeltaTest returns the delta test selected by -delta-test and
+// -paired, or nil if there is no such test. It exits if the test has
+// no paired form.
+func lookupDeltaTest() func(old, new *Benchstat) (float64, error) {
+	deltaTest := deltaTestNames[strings.ToLower(*flagDeltaTest)]
+	if *flagPaired && deltaTest != nil {
+		deltaTest = pairedDeltaTests[deltaTestKind()]
+		if deltaTest == nil {
+			log.Fatalf("-delta-test %s does not support -paired", *flagDeltaTest)
+		}
+	}
+	return deltaTest
+}
+
 var deltaTestNames = map[string]func(old, new *Benchstat) (float64, error){
 	"none":   notest,
 	"u":      utest,
@@ -44,6 +101,21 @@ var deltaTestNames = map[string]func(old, new *Benchstat) (float64, error){
 	"t":      ttest,
 	"t-test": ttest,
 	"ttest":  ttest,
+
+	// Trimmed-mean test.
+	"yuen":   yuentest,
+	"yuen-t": yuentest,
+
+	// Distribution tests.
+	"ks":      kstest,
+	"ks-test": kstest,
+	"kstest":  kstest,
+	"ad":      adtest,
+	"ad-test": adtest,
+	"adtest":  adtest,
+
+	// Bayesian comparison.
+	"bayes": bayestest,
 }
 
 type row struct {
@@ -67,22 +139,73 @@ func (r *row) trim() {
 func main() {
 	log.SetPrefix("benchstat: ")
 	log.SetFlags(0)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "save":
+			saveMain(os.Args[2:])
+			return
+		case "run":
+			runMain(os.Args[2:])
+			return
+		}
+	}
 	flag.Usage = usage
 	flag.Parse()
-	deltaTest := deltaTestNames[strings.ToLower(*flagDeltaTest)]
-	if flag.NArg() < 1 || deltaTest == nil {
+	deltaTest := lookupDeltaTest()
+	if _, ok := spreads[*flagSpread]; flag.NArg() < 1 || deltaTest == nil || !ok {
 		flag.Usage()
 	}
+	if *flagTrend && strings.ToLower(*flagDeltaTest) == "none" {
+		log.Fatal("-trend requires a delta test")
+	}
+	checkFlags()
 
 	// Read in benchmark data.
-	c := readFiles(flag.Args())
+	var c *Collection
+	if *flagStore != "" {
+		c = readStore(*flagStore, flag.Args())
+	} else {
+		c = readFiles(flag.Args())
+	}
 	for _, stat := range c.Stats {
 		stat.ComputeStats()
 	}
 
+	printTables(makeTables(c, deltaTest))
+	printMismatches(c)
+	if *flagScaling != "" && !*flagHTML {
+		printScalingPlots(c, *flagScaling)
+	}
+}
+
+// checkFlags exits if an analysis flag is out of range.
+func checkFlags() {
+	if *flagTrim < 0 || *flagTrim >= 50 {
+		log.Fatal("-trim must be at least 0 and less than 50")
+	}
+	if *flagPower < 0 || *flagPower >= 1 {
+		log.Fatal("-power must be between 0 and 1 (e.g., 0.8 for 80%)")
+	}
+}
+
+// makeTables returns the tables comparing the configs of c, along
+// with the notes their cells refer to.
+func makeTables(c *Collection, deltaTest func(old, new *Benchstat) (float64, error)) ([][]*row, noteList) {
 	var tables [][]*row
-	switch len(c.Configs) {
-	case 2:
+	var notes noteList
+	switch {
+	case *flagTrend:
+		return trendTables(c, deltaTest)
+	case *flagScaling != "":
+		return scalingTables(c, *flagScaling)
+	case *flagProcs:
+		return procsTables(c)
+	case *flagNoise:
+		return noiseTables(c)
+	case len(*flagQuantiles) > 0:
+		return quantileTables(c, *flagQuantiles)
+
+	case len(c.Configs) == 2:
 		before, after := c.Configs[0], c.Configs[1]
 		key := BenchKey{}
 		for _, key.Unit = range c.Units {
@@ -100,10 +223,44 @@ func main() {
 					table = append(table, newRow("name", "old "+metric, "new "+metric, "delta"))
 				}
 
+				if unitOf(key.Unit).exact {
+					// Deterministic counters need no
+					// test: any difference is real.
+					scaler := newScaler(old.Mean, old.Unit)
+					row := newRow(key.Benchmark, notes.cell(old, scaler, key.Benchmark, metric, before), notes.cell(new, scaler, key.Benchmark, metric, after), "~   ")
+					switch {
+					case *flagMargin > 0:
+						row.cols[3] = compareExact(old, new)
+					case old.Mean == 0 && new.Mean != 0:
+						// There is no percentage change
+						// from zero, so give the absolute one.
+						sign := "+"
+						if new.Mean < 0 {
+							sign = "-"
+						}
+						row.cols[3] = sign + scaler(math.Abs(new.Mean)) + changeWord(old.Unit, new.Mean-old.Mean)
+					case new.Mean != old.Mean:
+						row.cols[3] = formatDelta(old.Unit, old.Mean, new.Mean)
+					}
+					row.add("(exact)")
+					table = append(table, row)
+					continue
+				}
+
 				pval, testerr := deltaTest(old, new)
+				tost := -1.0
+				effect, small := "", false
+				if pval != -1 {
+					effect, small = effectSize(old, new)
+				}
 
 				scaler := newScaler(old.Mean, old.Unit)
-				row := newRow(key.Benchmark, old.Format(scaler), new.Format(scaler), "~   ")
+				row := newRow(key.Benchmark, notes.cell(old, scaler, key.Benchmark, metric, before), notes.cell(new, scaler, key.Benchmark, metric, after), "~   ")
+				if *flagMargin > 0 && (testerr == stats.ErrZeroVariance || testerr == stats.ErrSamplesEqual) {
+					// The samples are exact, so compare
+					// them directly.
+					row.cols[3] = compareExact(old, new)
+				}
 				if testerr == stats.ErrZeroVariance {
 					row.add("(zero variance)")
 				} else if testerr == stats.ErrSampleSize {
@@ -112,11 +269,45 @@ func main() {
 					row.add("(all equal)")
 				} else if testerr != nil {
 					row.add(fmt.Sprintf("(%s)", testerr))
-				} else if pval < *flagAlpha {
-					row.cols[3] = fmt.Sprintf("%+.2f%%", ((new.Mean/old.Mean)-1.0)*100.0)
+				} else if *flagMargin > 0 {
+					var eqerr error
+					tost, eqerr = equivTest(old, new, *flagMargin/100)
+					switch {
+					case eqerr == nil && tost < *flagAlpha:
+						row.cols[3] = "equivalent"
+					case pval != -1 && pval < *flagAlpha:
+						row.cols[3] = "different"
+					default:
+						row.cols[3] = "inconclusive"
+					}
+					if eqerr != nil {
+						tost = -1
+					}
+				} else if pval < *flagAlpha && !small {
+					row.cols[3] = formatDelta(old.Unit, old.Mean, new.Mean)
 				}
-				if len(row.cols) == 4 && pval != -1 {
-					row.add(fmt.Sprintf("(p=%0.3f n=%d+%d)", pval, len(old.RValues), len(new.RValues)))
+				if len(row.cols) == 4 && (pval != -1 || tost != -1) {
+					note := fmt.Sprintf("n=%d+%d", len(old.RValues), len(new.RValues))
+					switch deltaTestKind() {
+					case "yuen", "kstest", "adtest":
+						note = fmt.Sprintf("n=%d+%d", len(old.Values), len(new.Values))
+					}
+					if tost != -1 {
+						note = fmt.Sprintf("tost=%0.3f ", tost) + note
+					}
+					if pval != -1 && deltaTestKind() == "bayes" {
+						if bn := bayesNote(old, new); bn != "" {
+							note = bn + " " + note
+						}
+					} else if pval != -1 {
+						if *flagLog {
+							if ri := ratioInterval(old, new); ri != "" {
+								note = ri + " " + note
+							}
+						}
+						note = fmt.Sprintf("p=%0.3f %s ", pval, effect) + note
+					}
+					row.add("(" + note + powerNote(old, new) + ")")
 				}
 				table = append(table, row)
 			}
@@ -127,6 +318,10 @@ func main() {
 		}
 
 	default:
+		// With more than two configs, test whether each row
+		// differs across them at all.
+		omnibus := len(c.Configs) > 2 && deltaTestKind() != "none"
+		var omnibusMarker string
 		key := BenchKey{}
 		for _, key.Unit = range c.Units {
 			var table []*row
@@ -138,6 +333,12 @@ func main() {
 					hdr.add(config)
 				}
 				table = append(table, hdr)
+				if omnibus {
+					if omnibusMarker == "" {
+						omnibusMarker = notes.add(omnibusNote())
+					}
+					hdr.add(omnibusMarker)
+				}
 			} else {
 				table = append(table, newRow("name", metric))
 			}
@@ -145,8 +346,10 @@ func main() {
 			for _, key.Benchmark = range c.Benchmarks {
 				row := newRow(key.Benchmark)
 				var scaler func(float64) string
+				var rowStats []*Benchstat
 				for _, key.Config = range c.Configs {
 					stat := c.Stats[key]
+					rowStats = append(rowStats, stat)
 					if stat == nil {
 						row.add("")
 						continue
@@ -154,7 +357,10 @@ func main() {
 					if scaler == nil {
 						scaler = newScaler(stat.Mean, stat.Unit)
 					}
-					row.add(stat.Format(scaler))
+					row.add(notes.cell(stat, scaler, key.Benchmark, metric, key.Config))
+				}
+				if omnibus {
+					row.add(omnibusTest(rowStats))
 				}
 				row.trim()
 				if len(row.cols) > 1 {
@@ -166,6 +372,70 @@ func main() {
 		}
 	}
 
+	return tables, notes
+}
+
+// A noteList is a list of numbered notes about table cells, which
+// are printed after the tables.
+type noteList []string
+
+// add adds note to the list and returns the marker that refers to it.
+func (n *noteList) add(note string) string {
+	*n = append(*n, note)
+	return fmt.Sprintf("[%d]", len(*n))
+}
+
+// addOnce is like add, but if note is already in the list it returns
+// the existing marker instead of adding it again.
+func (n *noteList) addOnce(note string) string {
+	for i, s := range *n {
+		if s == note {
+			return fmt.Sprintf("[%d]", i+1)
+		}
+	}
+	return n.add(note)
+}
+
+// cell formats stat for a table cell, adding a note if the samples
+// are suspect. The benchmark, metric, and config describe the cell in
+// the note.
+func (n *noteList) cell(stat *Benchstat, scaler func(float64) string, benchmark, metric, config string) string {
+	s := stat.Format(scaler)
+	if unitOf(stat.Unit).exact && stat.Min < stat.Max {
+		s += " " + n.add(fmt.Sprintf("%s %s in %s varies from %s to %s, although %s is declared assume=exact; the comparison ignores the variation",
+			benchmark, metric, config, scaler(stat.Min), scaler(stat.Max), stat.Unit))
+	}
+	if len(stat.Modes) > 1 {
+		var modes []string
+		for _, mode := range stat.Modes {
+			modes = append(modes, scaler(mode))
+		}
+		s += " " + n.add(fmt.Sprintf("%s %s in %s is multimodal, with modes at %s; its mean may be misleading",
+			benchmark, metric, config, strings.Join(modes, ", ")))
+	}
+	if min, max := stat.iterRange(); 0 < min && min < lowIters {
+		iterations := "iterations"
+		if min == 1 {
+			iterations = "iteration"
+		}
+		ran := fmt.Sprintf("only %d %s per sample", min, iterations)
+		if min < max {
+			ran = fmt.Sprintf("a sample of only %d %s", min, iterations)
+		}
+		// The iteration counts are the same for every unit,
+		// so the cells of a benchmark share one note.
+		s += " " + n.addOnce(fmt.Sprintf("%s in %s ran %s; such samples include one-time costs such as warm-up and are unreliable",
+			benchmark, config, ran))
+	}
+	if why := stat.dependence(); why != "" {
+		s += " " + n.add(fmt.Sprintf("%s %s in %s %s; the samples are not independent, so p-values for it are not valid",
+			benchmark, metric, config, why))
+	}
+	return s
+}
+
+// printTables prints tables to standard output, followed by notes.
+func printTables(tables [][]*row, notes noteList) {
 	numColumn := 0
 	for _, table := range tables {
 		for _, row := range table {
@@ -243,6 +513,25 @@ func main() {
 		}
 	}
 
+	for i, note := range notes {
+		if *flagHTML {
+			fmt.Fprintf(&buf, "<p>[%d] %s</p>\n", i+1, html.EscapeString(note))
+			continue
+		}
+		if i == 0 {
+			fmt.Fprintf(&buf, "\n")
+		}
+		fmt.Fprintf(&buf, "[%d] %s\n", i+1, note)
+	}
+
+	if note := deltaTestNote(); note != "" && len(tables) > 0 {
+		if *flagHTML {
+			fmt.Fprintf(&buf, "<p>%s</p>\n", html.EscapeString(note))
+		} else {
+			fmt.Fprintf(&buf, "\n%s\n", note)
+		}
+	}
+
 	os.Stdout.Write(buf.Bytes())
 }
 
@@ -272,7 +561,7 @@ func addGeomean(table []*row, c *Collection, unit string, delta bool) []*row {
 		}
 	}
 	if delta {
-		row.add(fmt.Sprintf("%+.2f%%", ((geomeans[1]/geomeans[0])-1.0)*100.0))
+		row.add(formatDelta(unit, geomeans[0], geomeans[1]))
 	}
 	return append(table, row)
 }
@@ -312,7 +601,8 @@ func timeScaler(ns float64) func(float64) string {
 }
 
 func newScaler(val float64, unit string) func(float64) string {
-	if unit == "ns/op" {
+	kind := unitOf(unit).kind
+	if kind == "time" {
 		return timeScaler(val)
 	}
 
@@ -321,7 +611,7 @@ func newScaler(val float64, unit string) func(float64) string {
 	var suffix string
 
 	prescale := 1.0
-	if unit == "MB/s" {
+	if kind == "speed" {
 		prescale = 1e6
 	}
 
@@ -358,10 +648,10 @@ func newScaler(val float64, unit string) func(float64) string {
 		format, scale, suffix = "%.2f", 1, ""
 	}
 
-	if unit == "B/op" {
+	if kind == "bytes" {
 		suffix += "B"
 	}
-	if unit == "MB/s" {
+	if kind == "speed" {
 		suffix += "B/s"
 	}
 	scale /= prescale
@@ -376,8 +666,15 @@ func (b *Benchstat) Format(scaler func(float64) string) string {
 	if d := b.Max/b.Mean - 1; d > diff {
 		diff = d
 	}
+	if spread := spreads[*flagSpread]; spread != nil {
+		// The robust estimators need no outlier removal.
+		diff = 0
+		if len(b.Values) >= 2 {
+			diff = spread(stats.Sample{Xs: b.Values}) / b.Mean
+		}
+	}
 	s := scaler(b.Mean)
-	if b.Mean == 0 {
+	if b.Mean == 0 || unitOf(b.Unit).exact && b.Min == b.Max {
 		s += "     "
 	} else {
 		s = fmt.Sprintf("%s ±%3s", s, fmt.Sprintf("%.0f%%", diff*100.0))
@@ -385,24 +682,124 @@ func (b *Benchstat) Format(scaler func(float64) string) string {
 	return s
 }
 
+// lowIters is the iteration count below which a benchmark's samples
+// are flagged as unreliable.
+const lowIters = 10
+
+// iterRange returns the smallest and largest iteration counts of
+// stat's samples, or 0, 0 if they are unknown.
+func (stat *Benchstat) iterRange() (min, max int) {
+	for i, n := range stat.Iters {
+		if i == 0 || n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return min, max
+}
+
+// dependence describes how the samples of stat clearly depend on
+// their order, or returns "" if they do not. This uses a stricter
+// significance level than -alpha, since it checks every cell.
+func (stat *Benchstat) dependence() string {
+	const level = 0.01
+	if stat.Drift < 0 {
+		return ""
+	}
+	var why []string
+	if stat.Drift < level {
+		why = append(why, fmt.Sprintf("drifts over the runs (Mann-Kendall p=%0.3f)", stat.Drift))
+	}
+	// Under independence, the lag-1 autocorrelation is
+	// approximately normal with mean -1/n and variance 1/n.
+	n := float64(len(stat.Values))
+	if z := (stat.Autocorr + 1/n) * math.Sqrt(n); math.Abs(z) > -stats.StdNormal.InvCDF(level/2) {
+		why = append(why, fmt.Sprintf("has lag-1 autocorrelation %0.2f", stat.Autocorr))
+	}
+	return strings.Join(why, " and ")
+}
+
 // ComputeStats updates the derived statistics in s from the raw
 // samples in s.Values.
 func (stat *Benchstat) ComputeStats() {
-	// Discard outliers.
-	values := stats.Sample{Xs: stat.Values}
-	q1, q3 := values.Percentile(0.25), values.Percentile(0.75)
+	stat.computeSummary()
+	stat.computeDependence()
+	stat.computeModes()
+}
+
+// computeSummary sets RValues, Min, Mean, and Max, which are all the
+// delta tests need.
+func (stat *Benchstat) computeSummary() {
+	// Discard outliers. The Harrell-Davis quartiles are less
+	// jumpy than interpolated ones in small samples.
+	values := *stats.Sample{Xs: stat.Values}.Copy().Sort()
+	q1 := values.Quantile(0.25, stats.QuantileHarrellDavis)
+	q3 := values.Quantile(0.75, stats.QuantileHarrellDavis)
 	lo, hi := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
-	for _, value := range stat.Values {
+	if unitOf(stat.Unit).exact {
+		// Exact values have no outliers.
+		lo, hi = math.Inf(-1), math.Inf(1)
+	}
+	var weights []float64
+	for i, value := range stat.Values {
 		if lo <= value && value <= hi {
 			stat.RValues = append(stat.RValues, value)
+			if *flagWeighted && len(stat.Iters) == len(stat.Values) {
+				weights = append(weights, float64(stat.Iters[i]))
+			}
 		}
 	}
 
 	// Compute statistics of remaining data.
 	stat.Min, stat.Max = stats.Bounds(stat.RValues)
-	stat.Mean = stats.Mean(stat.RValues)
+	sample := stats.Sample{Xs: stat.RValues, Weights: weights}
+	stat.Mean = sample.Mean()
+	if *flagLog && stat.Min > 0 {
+		stat.Mean = sample.GeoMean()
+	}
+	if deltaTestKind() == "yuen" {
+		// Report the trimmed means that Yuen's test compares.
+		trim := *flagTrim / 100
+		if min, _ := stats.Bounds(stat.Values); *flagLog && min > 0 {
+			stat.Mean = math.Exp(logSample(stat.Values).TrimmedMean(trim))
+		} else if m := (stats.Sample{Xs: stat.Values}).TrimmedMean(trim); !math.IsNaN(m) {
+			stat.Mean = m
+		}
+	}
 }
 
+// computeDependence sets Drift and Autocorr. It checks that the
+// samples do not depend on their order, as they would if, say, the
+// machine heated up during the runs.
+func (stat *Benchstat) computeDependence() {
+	stat.Drift, stat.Autocorr = -1, 0
+	if len(stat.Values) >= minCheckSamples {
+		if mk, err := stats.MannKendallTest(stat.Values, stats.LocationDiffers); err == nil {
+			stat.Drift = mk.P
+			stat.Autocorr = stats.Autocorrelation(stat.Values, 1)
+		}
+	}
+}
+
+// computeModes sets Modes, looking for clusters in the data.
+// Silverman's rule oversmooths multimodal data a little, which keeps
+// noise in small samples from looking like structure.
+func (stat *Benchstat) computeModes() {
+	if len(stat.RValues) >= minCheckSamples && stat.Min < stat.Max {
+		sample := stats.Sample{Xs: stat.RValues}
+		kde := stats.KDE{Sample: sample, Kernel: stats.GaussianKernel, Bandwidth: stats.BandwidthSilverman(sample)}
+		if modes := kde.Modes(0.05); len(modes) > 1 {
+			stat.Modes = modes
+		}
+	}
+}
+
+// minCheckSamples is the smallest number of samples in which
+// ComputeStats looks for drift and multiple modes.
+const minCheckSamples = 6
+
 // A Benchstat is the metrics along one axis (e.g., ns/op or MB/s)
 // for all runs of a specific benchmark.
 type Benchstat struct {
@@ -410,8 +807,24 @@ type Benchstat struct {
 	Values  []float64 // metrics
 	RValues []float64 // metrics with outliers removed
 	Min     float64   // min of RValues
-	Mean    float64   // mean of RValues
+	Mean    float64   // mean of RValues (weighted by Iters for -weighted, trimmed mean of Values for -delta-test yuen)
 	Max     float64   // max of RValues
+	Modes   []float64 // modes of RValues, if there is more than one
+
+	// Drift is the p-value of a Mann-Kendall test for a trend in
+	// Values over the order they were read in, or -1 if there
+	// are too few samples to tell. Autocorr is the lag-1
+	// autocorrelation of Values.
+	Drift    float64
+	Autocorr float64
+
+	// Runs gives the value of the -pair-label label for each
+	// of Values, if -pair-label is set.
+	Runs []string
+
+	// Iters gives the iteration count (the benchmark's b.N) of
+	// each of Values.
+	Iters []int
 }
 
 // A BenchKey identifies one metric (e.g., "ns/op", "B/op") from one
@@ -424,6 +837,14 @@ type BenchKey struct {
 type Collection struct {
 	Stats map[BenchKey]*Benchstat
 
+	// Labels maps each config to the configuration labels (e.g.,
+	// "goos: linux" or "commit: 1a2b3c") that appeared in it,
+	// with the first value of each. LabelValues gives all of the
+	// distinct values of each, in the order they appeared, as
+	// for the pkg label of "go test ./..." output.
+	Labels      map[string]map[string]string
+	LabelValues map[string]map[string][]string
+
 	// Configs, Benchmarks, and Units give the set of configs,
 	// benchmarks, and units from the keys in Stats in an order
 	// meant to match the order the benchmarks were read in.
@@ -451,25 +872,74 @@ func (c *Collection) AddStat(key BenchKey) *Benchstat {
 	return stat
 }
 
+// AddLabel records the configuration label key: val for config.
+func (c *Collection) AddLabel(config, key, val string) {
+	labels := c.Labels[config]
+	if labels == nil {
+		labels = make(map[string]string)
+		c.Labels[config] = labels
+		c.LabelValues[config] = make(map[string][]string)
+	}
+	if _, ok := labels[key]; !ok {
+		labels[key] = val
+	}
+	for _, v := range c.LabelValues[config][key] {
+		if v == val {
+			return
+		}
+	}
+	c.LabelValues[config][key] = append(c.LabelValues[config][key], val)
+}
+
+func newCollection() *Collection {
+	return &Collection{
+		Stats:       make(map[BenchKey]*Benchstat),
+		Labels:      make(map[string]map[string]string),
+		LabelValues: make(map[string]map[string][]string),
+	}
+}
+
 // readFiles reads a set of benchmark files.
 func readFiles(files []string) *Collection {
-	c := Collection{Stats: make(map[BenchKey]*Benchstat)}
+	c := newCollection()
 	for _, file := range files {
-		readFile(file, &c)
+		readFile(file, c)
 	}
-	return &c
+	return c
 }
 
 // readFile reads a set of benchmarks from a file in to a Collection.
 func readFile(file string, c *Collection) {
 	c.Configs = append(c.Configs, file)
-	key := BenchKey{Config: file}
 
 	text, err := ioutil.ReadFile(file)
 	if err != nil {
 		log.Fatal(err)
 	}
-	for _, line := range strings.Split(string(text), "\n") {
+	readText(file, string(text), c)
+}
+
+// readText reads a set of benchmarks from text in to config of a
+// Collection.
+func readText(config, text string, c *Collection) {
+	key := BenchKey{Config: config}
+	run := ""
+	lines := strings.Split(text, "\n")
+	// Unit metadata applies to the whole input, wherever it
+	// appears.
+	for _, line := range lines {
+		if f := strings.Fields(line); len(f) > 0 && f[0] == "Unit" {
+			addUnitLine(f, c)
+		}
+	}
+	for _, line := range lines {
+		if k, v, ok := parseLabel(line); ok {
+			c.AddLabel(config, k, v)
+			if k == *flagPairLabel {
+				run = v
+			}
+			continue
+		}
 		f := strings.Fields(line)
 		if len(f) < 4 {
 			continue
@@ -492,11 +962,33 @@ func readFile(file string, c *Collection) {
 			}
 			key.Unit = f[i+1]
 			stat := c.AddStat(key)
-			stat.Values = append(stat.Values, val)
+			stat.Values = append(stat.Values, val*unitOf(key.Unit).factor)
+			stat.Iters = append(stat.Iters, n)
+			if *flagPairLabel != "" {
+				stat.Runs = append(stat.Runs, run)
+			}
 		}
 	}
 }
 
+// parseLabel parses a configuration line of the form "key: value",
+// where key starts with a lower-case letter and contains no spaces.
+func parseLabel(line string) (key, val string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 || line[0] < 'a' || line[0] > 'z' {
+		return "", "", false
+	}
+	key = line[:i]
+	if strings.ContainsAny(key, " \t") || strings.ToLower(key) != key {
+		return "", "", false
+	}
+	val = strings.TrimSpace(line[i+1:])
+	if i+1 < len(line) && line[i+1] != ' ' && line[i+1] != '\t' {
+		return "", "", false
+	}
+	return key, val, true
+}
+
 func metricOf(unit string) string {
 	switch unit {
 	case "ns/op":
@@ -512,18 +1004,117 @@ func metricOf(unit string) string {
 
 // Significance tests.
 
+// deltaTestKind returns the canonical name of the -delta-test flag.
+func deltaTestKind() string {
+	switch strings.ToLower(*flagDeltaTest) {
+	case "u", "u-test", "utest":
+		return "utest"
+	case "t", "t-test", "ttest":
+		return "ttest"
+	case "yuen", "yuen-t":
+		return "yuen"
+	case "ks", "ks-test", "kstest":
+		return "kstest"
+	case "ad", "ad-test", "adtest":
+		return "adtest"
+	case "bayes":
+		return "bayes"
+	}
+	return "none"
+}
+
+// deltaTestNote returns a note explaining the null hypothesis of the
+// -delta-test if it is not the usual one that the locations of the
+// samples are the same, or else "".
+func deltaTestNote() string {
+	switch deltaTestKind() {
+	case "yuen":
+		return fmt.Sprintf("Yuen's test: means are %g%% trimmed means of all values, outliers included, and p tests whether they differ.", *flagTrim)
+	case "kstest":
+		return "Kolmogorov-Smirnov test: p tests whether the distributions differ, not only their locations."
+	case "adtest":
+		return "Anderson-Darling test: p tests whether the distributions differ, not only their locations."
+	case "bayes":
+		return fmt.Sprintf("Bayesian comparison: intervals are %g%% credible intervals for the ratio of geometric means new/old; changes are reported if the posterior probability that they go the other way is below %g%%.", 100*(1-*flagAlpha), 100**flagAlpha/2)
+	}
+	return ""
+}
+
 func notest(old, new *Benchstat) (pval float64, err error) {
 	return -1, nil
 }
 
 func ttest(old, new *Benchstat) (pval float64, err error) {
-	t, err := stats.TwoSampleWelchTTest(stats.Sample{Xs: old.RValues}, stats.Sample{Xs: new.RValues}, stats.LocationDiffers)
+	x1, x2, err := tSamples(old, new)
+	if err != nil {
+		return -1, err
+	}
+	t, err := stats.TwoSampleWelchTTest(x1, x2, stats.LocationDiffers)
 	if err != nil {
 		return -1, err
 	}
 	return t.P, nil
 }
 
+// tSamples returns the samples of old and new to use in t-tests: the
+// values or, with -log, their logarithms. A t-test on logarithms
+// compares geometric means, and is appropriate for changes that
+// scale the values rather than add to them.
+func tSamples(old, new *Benchstat) (x1, x2 stats.Sample, err error) {
+	x1, x2 = stats.Sample{Xs: old.RValues}, stats.Sample{Xs: new.RValues}
+	if !*flagLog || old.Min == old.Max && new.Min == new.Max {
+		// Let the test report zero variance.
+		return x1, x2, nil
+	}
+	if old.Min <= 0 || new.Min <= 0 {
+		return x1, x2, errNonPositive
+	}
+	return logSample(old.RValues), logSample(new.RValues), nil
+}
+
+// ratioInterval returns a confidence interval for the ratio of the
+// geometric mean of new to that of old for the row's note, from the
+// t-test on the logarithms. It is symmetric on the log scale.
+func ratioInterval(old, new *Benchstat) string {
+	x1, x2, err := tSamples(old, new)
+	if err != nil {
+		return ""
+	}
+	lo, hi, err := stats.TwoSampleWelchTTestInterval(x2, x1, 1-*flagAlpha)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("ratio in [%.3f, %.3f]", math.Exp(lo), math.Exp(hi))
+}
+
+func yuentest(old, new *Benchstat) (pval float64, err error) {
+	x1, x2, err := yuenSamples(old, new)
+	if err != nil {
+		return -1, err
+	}
+	t, err := stats.YuenTTest(x1, x2, *flagTrim/100, stats.LocationDiffers)
+	if err != nil {
+		return -1, err
+	}
+	return t.P, nil
+}
+
+// yuenSamples returns the samples of old and new to use in Yuen's
+// test. Since trimming takes care of outliers, these are all of the
+// values, or with -log their logarithms.
+func yuenSamples(old, new *Benchstat) (x1, x2 stats.Sample, err error) {
+	x1, x2 = stats.Sample{Xs: old.Values}, stats.Sample{Xs: new.Values}
+	if !*flagLog {
+		return x1, x2, nil
+	}
+	min1, _ := stats.Bounds(old.Values)
+	min2, _ := stats.Bounds(new.Values)
+	if min1 <= 0 || min2 <= 0 {
+		return x1, x2, errNonPositive
+	}
+	return logSample(old.Values), logSample(new.Values), nil
+}
+
 func utest(old, new *Benchstat) (pval float64, err error) {
 	u, err := stats.MannWhitneyUTest(old.RValues, new.RValues, stats.LocationDiffers)
 	if err != nil {
@@ -531,3 +1122,241 @@ func utest(old, new *Benchstat) (pval float64, err error) {
 	}
 	return u.P, nil
 }
+
+// kstest and adtest compare the whole distributions, so they keep
+// the outliers, which may be the very tail they are meant to see.
+func kstest(old, new *Benchstat) (pval float64, err error) {
+	k, err := stats.KolmogorovSmirnovTest(old.Values, new.Values)
+	if err != nil {
+		return -1, err
+	}
+	return k.P, nil
+}
+
+func adtest(old, new *Benchstat) (pval float64, err error) {
+	a, err := stats.AndersonDarlingTest(old.Values, new.Values)
+	if err != nil {
+		return -1, err
+	}
+	return a.P, nil
+}
+
+// bayesDraws is the number of posterior draws for -delta-test bayes.
+const bayesDraws = 4000
+
+// bayesCheck returns an error if old and new cannot be compared by
+// -delta-test bayes.
+func bayesCheck(old, new *Benchstat) error {
+	if len(old.RValues) < 2 || len(new.RValues) < 2 {
+		return stats.ErrSampleSize
+	}
+	if old.Min == old.Max && new.Min == new.Max {
+		return stats.ErrZeroVariance
+	}
+	if old.Min <= 0 || new.Min <= 0 {
+		return errNonPositive
+	}
+	return nil
+}
+
+var errNonPositive = errors.New("non-positive values")
+
+// logSample returns the sample of the logarithms of xs.
+//
+// The Bayesian comparison works with logarithms, which makes the
+// difference of the means the log of the ratio of geometric means.
+// This suits performance measurements, which vary multiplicatively.
+func logSample(xs []float64) stats.Sample {
+	ls := make([]float64, len(xs))
+	for i, x := range xs {
+		ls[i] = math.Log(x)
+	}
+	return stats.Sample{Xs: ls}
+}
+
+// bayestest compares old and new using the posterior distribution of
+// the ratio of their geometric means. In place of a p-value, it
+// returns twice the posterior probability that the ratio is on the
+// other side of 1 from the observed ratio, which is small when the
+// posterior confidently puts the change on one side.
+func bayestest(old, new *Benchstat) (pval float64, err error) {
+	if err := bayesCheck(old, new); err != nil {
+		return -1, err
+	}
+	up := stats.NormalMeanPosteriorGreater(logSample(new.RValues), logSample(old.RValues))
+	return math.Min(1, 2*math.Min(up, 1-up)), nil
+}
+
+// bayesNote summarizes the posterior distribution of the ratio of
+// the geometric mean of new to that of old: the probability that new is
+// worse than old by more than -margin (or 1%), and a credible
+// interval for the ratio.
+func bayesNote(old, new *Benchstat) string {
+	if bayesCheck(old, new) != nil {
+		return ""
+	}
+	// Draw from the posterior. The draws are the same every time
+	// for the same samples, so that repeated runs agree.
+	r := rand.New(rand.NewSource(1))
+	m1 := stats.NormalMeanPosterior(logSample(old.RValues), bayesDraws, r)
+	m2 := stats.NormalMeanPosterior(logSample(new.RValues), bayesDraws, r)
+	ratios := make([]float64, len(m1))
+	for i := range ratios {
+		ratios[i] = math.Exp(m2[i] - m1[i])
+	}
+	sort.Float64s(ratios)
+
+	margin := 1.0
+	if *flagMargin > 0 {
+		margin = *flagMargin
+	}
+	u := unitOf(old.Unit)
+	word := "larger"
+	switch {
+	case u.kind == "time" && u.better < 0, u.kind == "speed" && u.better > 0:
+		word = "slower"
+	case u.better != 0:
+		word = "worse"
+	}
+	worse := 0
+	for _, ratio := range ratios {
+		if u.better > 0 && ratio < 1-margin/100 || u.better <= 0 && ratio > 1+margin/100 {
+			worse++
+		}
+	}
+	ci := stats.Sample{Xs: ratios, Sorted: true}
+	return fmt.Sprintf("P(%s by >%g%%)=%.2f ratio in [%.3f, %.3f]", word, margin,
+		float64(worse)/float64(len(ratios)), ci.Percentile(*flagAlpha/2), ci.Percentile(1-*flagAlpha/2))
+}
+
+// pairs returns the paired samples of old and new. Samples are
+// paired by their -pair-label label if it is set, or else by the
+// order they were read in. It returns errFewPairs if fewer than two
+// samples pair up.
+func pairs(old, new *Benchstat) (x1, x2 []float64, err error) {
+	if *flagPairLabel == "" {
+		n := len(old.Values)
+		if len(new.Values) < n {
+			n = len(new.Values)
+		}
+		x1, x2 = old.Values[:n], new.Values[:n]
+	} else {
+		x1, x2 = pairsByLabel(old, new)
+	}
+	if len(x1) < 2 {
+		return nil, nil, errFewPairs
+	}
+	return x1, x2, nil
+}
+
+// errFewPairs is the error for samples with fewer than two pairs.
+var errFewPairs = errors.New("too few pairs")
+
+// pairsByLabel returns the samples of old and new paired by their
+// -pair-label label.
+func pairsByLabel(old, new *Benchstat) (x1, x2 []float64) {
+	index := make(map[string]int)
+	for i, run := range new.Runs {
+		if _, ok := index[run]; !ok {
+			index[run] = i
+		}
+	}
+	for i, run := range old.Runs {
+		if j, ok := index[run]; ok {
+			x1 = append(x1, old.Values[i])
+			x2 = append(x2, new.Values[j])
+			delete(index, run)
+		}
+	}
+	return x1, x2
+}
+
+func pairedTTest(old, new *Benchstat) (pval float64, err error) {
+	x1, x2, err := pairs(old, new)
+	if err != nil {
+		return -1, err
+	}
+	// The pairs include outliers, so Min does not tell whether
+	// they are all positive.
+	min1, _ := stats.Bounds(x1)
+	min2, _ := stats.Bounds(x2)
+	if *flagLog && min1 > 0 && min2 > 0 {
+		// Test the ratios of the pairs.
+		x1, x2 = logSample(x1).Xs, logSample(x2).Xs
+	}
+	t, err := stats.PairedTTest(x1, x2, 0, stats.LocationDiffers)
+	if err != nil {
+		return -1, err
+	}
+	return t.P, nil
+}
+
+func signedRankTest(old, new *Benchstat) (pval float64, err error) {
+	x1, x2, err := pairs(old, new)
+	if err != nil {
+		return -1, err
+	}
+	w, err := stats.WilcoxonSignedRankTest(x1, x2, stats.LocationDiffers)
+	if err != nil {
+		return -1, err
+	}
+	return w.P, nil
+}
+
+// effectSize returns the size of the change from old to new for the
+// row's note, and whether it is smaller than -min-effect. This is
+// Hedges' g if -delta-test is a t-test, and otherwise the probability
+// that a run of new is less than a run of old, which is tested
+// against -min-effect as Cliff's δ.
+func effectSize(old, new *Benchstat) (note string, small bool) {
+	if deltaTestKind() == "ttest" {
+		x1, x2, _ := tSamples(old, new)
+		g := stats.HedgesG(x2, x1)
+		return fmt.Sprintf("g=%.2f", g), math.Abs(g) < *flagMinEffect
+	}
+	cles := stats.CommonLanguageEffect(old.RValues, new.RValues)
+	return fmt.Sprintf("P(new<old)=%.2f", cles), math.Abs(2*cles-1) < *flagMinEffect
+}
+
+// compareExact compares the means of old and new, whose samples are
+// exact, against -margin. They are "equivalent" if they are equal or
+// within ±margin times the mean of old, and otherwise "different".
+func compareExact(old, new *Benchstat) string {
+	if new.Mean == old.Mean || math.Abs(new.Mean-old.Mean) < *flagMargin/100*math.Abs(old.Mean) {
+		return "equivalent"
+	}
+	return "different"
+}
+
+// equivTest tests whether new is equivalent to old within ±margin
+// times the mean of old, using the two one-sided tests procedure. It
+// uses Welch's t-test if -delta-test is a t-test and the U-test
+// otherwise, or with -paired, their paired forms.
+func equivTest(old, new *Benchstat, margin float64) (pval float64, err error) {
+	bound := margin * math.Abs(old.Mean)
+	var t *stats.TOSTResult
+	if *flagPaired {
+		x1, x2, err := pairs(old, new)
+		if err != nil {
+			return -1, err
+		}
+		if deltaTestKind() == "ttest" {
+			t, err = stats.PairedTOST(x2, x1, -bound, bound)
+		} else {
+			t, err = stats.WilcoxonSignedRankTOST(x2, x1, -bound, bound)
+		}
+		if err != nil {
+			return -1, err
+		}
+		return t.P, nil
+	}
+	if deltaTestKind() == "ttest" {
+		t, err = stats.TwoSampleWelchTOST(stats.Sample{Xs: new.RValues}, stats.Sample{Xs: old.RValues}, -bound, bound)
+	} else {
+		t, err = stats.MannWhitneyTOST(new.RValues, old.RValues, -bound, bound)
+	}
+	if err != nil {
+		return -1, err
+	}
+	return t.P, nil
+}
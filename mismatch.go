@@ -0,0 +1,160 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// mismatchLabels are the configuration labels that should match
+// between two configs for their results to be comparable.
+var mismatchLabels = []string{"goos", "goarch", "cpu", "pkg"}
+
+// printMismatches prints the benchmarks that are only in one of the
+// two configs of c, which the comparison leaves out, and warnings
+// about differences between the configs that make the comparison
+// suspect.
+func printMismatches(c *Collection) {
+	if len(c.Configs) != 2 || *flagTrend {
+		return
+	}
+	lines := mismatches(c)
+	if len(lines) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	if !*flagHTML {
+		fmt.Fprintf(&buf, "\n")
+	}
+	for _, line := range lines {
+		if *flagHTML {
+			fmt.Fprintf(&buf, "<p>%s</p>\n", html.EscapeString(line))
+			continue
+		}
+		fmt.Fprintf(&buf, "%s\n", line)
+	}
+	os.Stdout.Write(buf.Bytes())
+}
+
+// mismatches returns the lines of the mismatch report for the two
+// configs of c.
+func mismatches(c *Collection) []string {
+	old, new := c.Configs[0], c.Configs[1]
+	warnings, covered := procsMismatches(c)
+
+	// Benchmarks in only one config.
+	var lines, onlyOld, onlyNew []string
+	for _, benchmark := range c.Benchmarks {
+		if covered[benchmark] {
+			continue
+		}
+		inOld, inNew := hasBenchmark(c, old, benchmark), hasBenchmark(c, new, benchmark)
+		switch {
+		case inOld && !inNew:
+			onlyOld = append(onlyOld, benchmark)
+		case inNew && !inOld:
+			onlyNew = append(onlyNew, benchmark)
+		}
+	}
+	if len(onlyOld) > 0 {
+		lines = append(lines, fmt.Sprintf("only in %s: %s", old, strings.Join(onlyOld, ", ")))
+	}
+	if len(onlyNew) > 0 {
+		lines = append(lines, fmt.Sprintf("only in %s: %s", new, strings.Join(onlyNew, ", ")))
+	}
+
+	// Configuration labels. A config may have several values of
+	// a label, such as one pkg per package, so compare them as
+	// sets.
+	for _, label := range mismatchLabels {
+		v1, v2 := labelSet(c, old, label), labelSet(c, new, label)
+		if v1 != "" && v2 != "" && v1 != v2 {
+			warnings = append(warnings, fmt.Sprintf("%s is %s in %s but %s in %s", label, v1, old, v2, new))
+		}
+	}
+
+	// Sample counts.
+	for _, benchmark := range c.Benchmarks {
+		s1, s2 := firstStat(c, old, benchmark), firstStat(c, new, benchmark)
+		if s1 != nil && s2 != nil && len(s1.Values) != len(s2.Values) {
+			warnings = append(warnings, fmt.Sprintf("%s has %d samples in %s but %d in %s", benchmark, len(s1.Values), old, len(s2.Values), new))
+		}
+	}
+
+	// Iteration counts. The testing package picks each sample's
+	// iteration count so that it runs for about -benchtime, so a
+	// faster benchmark runs more iterations in the same time.
+	// Iteration counts that differ by more than the change in
+	// time/op explains suggest different -benchtime settings.
+	// Equal counts, as from -benchtime=Nx, need no explaining.
+	var benchtime []string
+	for _, benchmark := range c.Benchmarks {
+		key := BenchKey{Benchmark: benchmark, Unit: "ns/op"}
+		key.Config = old
+		n1, t1 := sampleTime(c.Stats[key])
+		key.Config = new
+		n2, t2 := sampleTime(c.Stats[key])
+		if n1 > 0 && n2 > 0 && n1 != n2 && math.Max(t1, t2)/math.Min(t1, t2) > 3 {
+			benchtime = append(benchtime, fmt.Sprintf("%s (%.0f vs %.0f)", benchmark, n1, n2))
+		}
+	}
+	if len(benchtime) > 0 {
+		warnings = append(warnings, fmt.Sprintf("iteration counts per sample differ between %s and %s by more than the change in time/op explains for %s; were they run with different -benchtime settings?", old, new, strings.Join(benchtime, ", ")))
+	}
+
+	for _, w := range warnings {
+		lines = append(lines, "warning: "+w)
+	}
+	return lines
+}
+
+// labelSet returns the distinct values of label in config, sorted
+// and joined by commas, or "" if it has none.
+func labelSet(c *Collection, config, label string) string {
+	values := append([]string(nil), c.LabelValues[config][label]...)
+	sort.Strings(values)
+	return strings.Join(values, ", ")
+}
+
+// hasBenchmark reports whether config has results for benchmark.
+func hasBenchmark(c *Collection, config, benchmark string) bool {
+	return firstStat(c, config, benchmark) != nil
+}
+
+// firstStat returns the results for benchmark in config in the first
+// unit it has, or nil if it has none.
+func firstStat(c *Collection, config, benchmark string) *Benchstat {
+	for _, unit := range c.Units {
+		if stat := c.Stats[BenchKey{Config: config, Benchmark: benchmark, Unit: unit}]; stat != nil {
+			return stat
+		}
+	}
+	return nil
+}
+
+// sampleTime returns the median iteration count of the samples of
+// stat, a time/op metric, and the median time in nanoseconds that
+// they ran for, counting all of their iterations. It returns 0, 0 if
+// stat is nil or its iteration counts are unknown.
+func sampleTime(stat *Benchstat) (iters, ns float64) {
+	if stat == nil || len(stat.Iters) != len(stat.Values) || len(stat.Values) == 0 {
+		return 0, 0
+	}
+	var counts, times []float64
+	for i, v := range stat.Values {
+		counts = append(counts, float64(stat.Iters[i]))
+		times = append(times, v*float64(stat.Iters[i]))
+	}
+	median := func(xs []float64) float64 { return stats.Sample{Xs: xs}.Quantile(0.5, stats.QuantileR7) }
+	return median(counts), median(times)
+}
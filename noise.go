@@ -0,0 +1,102 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// noiseTables reports how noisy each benchmark is in each config of
+// c, ordered from the most to the least stable.
+//
+// The columns are the coefficient of variation of the samples, the
+// fraction of samples discarded as outliers, the median absolute
+// deviation relative to the median, and the number of modes if the
+// samples have more than one. The rows are ordered by the relative MAD, which
+// is not thrown off by a few outliers the way the coefficient of
+// variation is.
+func noiseTables(c *Collection) ([][]*row, noteList) {
+	type noise struct {
+		benchmark, config string
+		n                 int
+		cv, outliers, mad float64
+		modes             int
+	}
+
+	var tables [][]*row
+	key := BenchKey{}
+	for _, key.Unit = range c.Units {
+		var rows []noise
+		for _, key.Benchmark = range c.Benchmarks {
+			for _, key.Config = range c.Configs {
+				stat := c.Stats[key]
+				if stat == nil {
+					continue
+				}
+				sample := *stats.Sample{Xs: stat.Values}.Copy().Sort()
+				mean, median := sample.Mean(), sample.Quantile(0.5, stats.QuantileHarrellDavis)
+				devs := make([]float64, len(stat.Values))
+				for i, x := range stat.Values {
+					devs[i] = math.Abs(x - median)
+				}
+				mad := stats.Sample{Xs: devs}.Quantile(0.5, stats.QuantileHarrellDavis)
+				rows = append(rows, noise{
+					benchmark: key.Benchmark,
+					config:    key.Config,
+					n:         len(stat.Values),
+					cv:        relative(sample.StdDev(), mean),
+					outliers:  1 - float64(len(stat.RValues))/float64(len(stat.Values)),
+					mad:       relative(mad, median),
+					modes:     len(stat.Modes),
+				})
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i].mad != rows[j].mad {
+				return rows[i].mad < rows[j].mad
+			}
+			return rows[i].cv < rows[j].cv
+		})
+
+		hdr := newRow("name")
+		if len(c.Configs) > 1 {
+			hdr.add("config")
+		}
+		hdr.cols = append(hdr.cols, "n", metricOf(key.Unit)+" CV", "outliers", "MAD/median", "modes")
+		table := []*row{hdr}
+		for _, r := range rows {
+			row := newRow(r.benchmark)
+			if len(c.Configs) > 1 {
+				row.add(r.config)
+			}
+			row.add(fmt.Sprint(r.n))
+			row.add(fmt.Sprintf("%.1f%%", r.cv*100))
+			row.add(fmt.Sprintf("%.0f%%", r.outliers*100))
+			row.add(fmt.Sprintf("%.1f%%", r.mad*100))
+			if r.modes > 1 {
+				row.add(fmt.Sprint(r.modes))
+			}
+			table = append(table, row)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// relative returns x relative to the magnitude of base, or 0 if base
+// is 0.
+func relative(x, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return x / math.Abs(base)
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// omnibusNote returns the note explaining the omnibus column of the
+// multi-config table.
+func omnibusNote() string {
+	if *flagPaired {
+		return "p tests whether the benchmark differs across the configurations at all (Friedman test, with runs as blocks)."
+	}
+	return "p tests whether the benchmark differs across the configurations at all (Kruskal-Wallis test)."
+}
+
+// omnibusTest tests whether the stats of one benchmark differ across
+// the configs and returns the result for the row's note. Configs
+// without results are left out of the test.
+func omnibusTest(row []*Benchstat) string {
+	var present []*Benchstat
+	for _, stat := range row {
+		if stat != nil {
+			present = append(present, stat)
+		}
+	}
+	if len(present) < 2 {
+		return ""
+	}
+	if unitOf(present[0].Unit).exact {
+		return "(exact)"
+	}
+
+	var pval float64
+	var n []string
+	var err error
+	if *flagPaired {
+		blocks := runBlocks(present)
+		var f *stats.FriedmanTestResult
+		if f, err = stats.FriedmanTest(blocks); err == nil {
+			pval = f.P
+			n = append(n, fmt.Sprint(f.Blocks))
+		}
+	} else {
+		var samples [][]float64
+		for _, stat := range present {
+			samples = append(samples, stat.RValues)
+			n = append(n, fmt.Sprint(len(stat.RValues)))
+		}
+		var kw *stats.KruskalWallisTestResult
+		if kw, err = stats.KruskalWallisTest(samples...); err == nil {
+			pval = kw.P
+		}
+	}
+	switch err {
+	case nil:
+		return fmt.Sprintf("(p=%0.3f n=%s)", pval, strings.Join(n, "+"))
+	case stats.ErrSampleSize:
+		return "(too few samples)"
+	case stats.ErrSamplesEqual:
+		return "(all equal)"
+	}
+	return fmt.Sprintf("(%s)", err)
+}
+
+// runBlocks groups the values of row into blocks of one value from
+// each stat, pairing them by run order or by -pair-label as pairs
+// does for two stats. Runs missing from any stat are left out.
+func runBlocks(row []*Benchstat) [][]float64 {
+	var blocks [][]float64
+	if *flagPairLabel == "" {
+		n := len(row[0].Values)
+		for _, stat := range row[1:] {
+			if len(stat.Values) < n {
+				n = len(stat.Values)
+			}
+		}
+		for i := 0; i < n; i++ {
+			var block []float64
+			for _, stat := range row {
+				block = append(block, stat.Values[i])
+			}
+			blocks = append(blocks, block)
+		}
+		return blocks
+	}
+
+	// Use the first result of each run in each stat.
+	index := make([]map[string]int, len(row))
+	for j, stat := range row {
+		index[j] = make(map[string]int)
+		for i, run := range stat.Runs {
+			if _, ok := index[j][run]; !ok {
+				index[j][run] = i
+			}
+		}
+	}
+Runs:
+	for i, run := range row[0].Runs {
+		if index[0][run] != i {
+			continue
+		}
+		block := []float64{row[0].Values[i]}
+		for j, stat := range row[1:] {
+			k, ok := index[j+1][run]
+			if !ok {
+				continue Runs
+			}
+			block = append(block, stat.Values[k])
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// powerTrials is the number of simulations used to estimate the power
+// of the U-test.
+const powerTrials = 500
+
+// powerNote returns a note reporting, for the -power flag, the
+// minimum relative change between old and new that the delta test
+// can detect with the current sample sizes, and the sample size
+// needed to detect a change of -power-effect. It returns "" if -power
+// is not set or the power cannot be computed.
+func powerNote(old, new *Benchstat) string {
+	if *flagPower <= 0 {
+		return ""
+	}
+	n1, n2 := len(old.RValues), len(new.RValues)
+	sd1, sd2 := stats.StdDev(old.RValues), stats.StdDev(new.RValues)
+	if n1 < 2 || n2 < 2 || sd1 == 0 && sd2 == 0 || old.Mean == 0 {
+		return ""
+	}
+	effect := *flagEffect / 100
+
+	var mde float64
+	var need int
+	switch deltaTestKind() {
+	case "ttest":
+		mde = stats.WelchTTestMinEffect(sd1, sd2, n1, n2, *flagAlpha, *flagPower, stats.LocationDiffers) / math.Abs(old.Mean)
+		need = stats.WelchTTestSampleSize(effect*old.Mean, sd1, sd2, *flagAlpha, *flagPower, stats.LocationDiffers)
+	case "utest":
+		// Simulate samples from the kernel density estimates
+		// of old and new, with new rescaled so its mean is a
+		// given change from old's.
+		d1 := fitKDE(old.RValues, 1)
+		d2 := func(change float64) stats.DistCommon {
+			return fitKDE(new.RValues, old.Mean*(1+change)/new.Mean)
+		}
+		power := func(change float64) float64 {
+			r := rand.New(rand.NewSource(1))
+			return stats.MannWhitneyUTestPower(d1, d2(change), n1, n2, *flagAlpha, stats.LocationDiffers, powerTrials, r)
+		}
+		mde = minEffect(power, *flagPower)
+		need = stats.MannWhitneyUTestSampleSize(d1, d2(effect), *flagAlpha, *flagPower, stats.LocationDiffers, powerTrials, rand.New(rand.NewSource(1)))
+	default:
+		return ""
+	}
+
+	note := " mde=?"
+	if !math.IsNaN(mde) {
+		note = fmt.Sprintf(" mde=%.1f%%", mde*100)
+	}
+	if need == 0 {
+		return note + fmt.Sprintf(" need>%d", stats.SampleSizeLimit)
+	}
+	return note + fmt.Sprintf(" need=%d", need)
+}
+
+// fitKDE returns a kernel density estimate of the distribution of xs
+// scaled by the given factor.
+func fitKDE(xs []float64, scale float64) *stats.KDE {
+	sample := stats.Sample{Xs: make([]float64, len(xs))}
+	for i, x := range xs {
+		sample.Xs[i] = x * scale
+	}
+	return &stats.KDE{Sample: sample, Bandwidth: stats.BandwidthScott(sample)}
+}
+
+// minEffect returns the smallest relative change for which power
+// reaches target, or NaN if no change up to 1000% does.
+func minEffect(power func(change float64) float64, target float64) float64 {
+	lo, hi := 0.0, 0.01
+	for power(hi) < target {
+		if hi > 10 {
+			return math.NaN()
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 0.0005 {
+		mid := (lo + hi) / 2
+		if power(mid) >= target {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
@@ -0,0 +1,304 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// splitProcs splits the -N suffix that the testing package adds to
+// benchmark names for GOMAXPROCS > 1 off benchmark and returns the
+// rest of the name and N. Names without a suffix ran with
+// GOMAXPROCS=1.
+func splitProcs(benchmark string) (base string, procs int) {
+	if i := strings.LastIndex(benchmark, "-"); i > 0 {
+		if n, err := strconv.Atoi(benchmark[i+1:]); err == nil && n > 0 {
+			return benchmark[:i], n
+		}
+	}
+	return benchmark, 1
+}
+
+// A procsGroup is a benchmark run at several GOMAXPROCS settings.
+type procsGroup struct {
+	name       string   // benchmark name without the -N suffix
+	benchmarks []string // the benchmarks in the group, by procs
+	procs      []int    // procs of each benchmark, in increasing order
+}
+
+// procsGroups groups the benchmarks of c that ran at more than one
+// GOMAXPROCS setting, in order of first appearance.
+func procsGroups(c *Collection) []*procsGroup {
+	var groups []*procsGroup
+	byName := make(map[string]*procsGroup)
+	for _, benchmark := range c.Benchmarks {
+		name, procs := splitProcs(benchmark)
+		g := byName[name]
+		if g == nil {
+			g = &procsGroup{name: name}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.benchmarks = append(g.benchmarks, benchmark)
+		g.procs = append(g.procs, procs)
+	}
+	var multi []*procsGroup
+	for _, g := range groups {
+		if len(g.procs) < 2 {
+			continue
+		}
+		sort.Sort(byProcs{g})
+		multi = append(multi, g)
+	}
+	return multi
+}
+
+type byProcs struct{ g *procsGroup }
+
+func (b byProcs) Len() int           { return len(b.g.procs) }
+func (b byProcs) Less(i, j int) bool { return b.g.procs[i] < b.g.procs[j] }
+func (b byProcs) Swap(i, j int) {
+	b.g.procs[i], b.g.procs[j] = b.g.procs[j], b.g.procs[i]
+	b.g.benchmarks[i], b.g.benchmarks[j] = b.g.benchmarks[j], b.g.benchmarks[i]
+}
+
+// procsMismatches describes the benchmarks that ran with different
+// GOMAXPROCS settings in the two configs of c, which therefore have
+// different names and are not compared. It returns the descriptions
+// and the set of names, with their -N suffixes, that they cover.
+func procsMismatches(c *Collection) (warnings []string, covered map[string]bool) {
+	procs := make(map[string][2][]string)
+	var names []string
+	for _, benchmark := range c.Benchmarks {
+		name, n := splitProcs(benchmark)
+		p, ok := procs[name]
+		if !ok {
+			names = append(names, name)
+		}
+		for i, config := range c.Configs[:2] {
+			if hasBenchmark(c, config, benchmark) {
+				p[i] = append(p[i], strconv.Itoa(n))
+			}
+		}
+		procs[name] = p
+	}
+	covered = make(map[string]bool)
+	for _, name := range names {
+		p := procs[name]
+		if len(p[0]) == 0 || len(p[1]) == 0 || intersects(p[0], p[1]) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s ran with GOMAXPROCS=%s in %s but GOMAXPROCS=%s in %s, so it is not compared", name, strings.Join(p[0], ","), c.Configs[0], strings.Join(p[1], ","), c.Configs[1]))
+		for _, benchmark := range c.Benchmarks {
+			if base, _ := splitProcs(benchmark); base == name {
+				covered[benchmark] = true
+			}
+		}
+	}
+	return warnings, covered
+}
+
+func intersects(x, y []string) bool {
+	for _, a := range x {
+		for _, b := range y {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// procsTables reports how the benchmarks of c that ran at several
+// GOMAXPROCS settings scale with the number of procs: their speedup
+// over the fewest procs they ran with (normally 1) and their parallel
+// efficiency, the speedup per proc. With two configs, it tests
+// whether the speedup changed.
+//
+// Only times and speeds, including custom units tidied to them, are
+// reported, since a speedup of other metrics has no clear meaning.
+func procsTables(c *Collection) ([][]*row, noteList) {
+	var tables [][]*row
+	var notes noteList
+	groups := procsGroups(c)
+	if len(groups) == 0 {
+		log.Fatal("no benchmarks ran at more than one GOMAXPROCS setting")
+	}
+	marker := notes.add(fmt.Sprintf("Speedups are relative to the fewest procs each benchmark ran with, with %g%% confidence intervals; efficiency is speedup per proc relative to that baseline.", 100*(1-*flagAlpha)))
+	for _, unit := range c.Units {
+		if kind := unitOf(unit).kind; kind != "time" && kind != "speed" {
+			continue
+		}
+		metric := metricOf(unit)
+		var table []*row
+		switch len(c.Configs) {
+		case 1:
+			table = append(table, newRow("name", "procs", metric, "speedup "+marker, "efficiency"))
+		case 2:
+			table = append(table, newRow("name", "procs", "old speedup "+marker, "new speedup", "delta"))
+		default:
+			hdr := newRow("name \\ speedup "+marker, "procs")
+			for _, config := range c.Configs {
+				hdr.add(config)
+			}
+			table = append(table, hdr)
+		}
+		for _, g := range groups {
+			// Report the group if any of its runs has a
+			// speedup, along with its baseline.
+			var rows []*row
+			report := false
+			for i, benchmark := range g.benchmarks {
+				row := newRow(g.name, strconv.Itoa(g.procs[i]))
+				if i == 0 {
+					row.cols[1] += " (base)"
+				}
+				var speedups []*speedup
+				for _, config := range c.Configs {
+					key := BenchKey{Config: config, Benchmark: g.benchmarks[0], Unit: unit}
+					base := c.Stats[key]
+					key.Benchmark = benchmark
+					stat := c.Stats[key]
+					s := newSpeedup(base, stat)
+					speedups = append(speedups, s)
+					single := len(c.Configs) == 1
+					if single && stat != nil {
+						row.add(stat.Format(newScaler(stat.Mean, unit)))
+					} else if single {
+						row.add("")
+					}
+					if s == nil || i == 0 {
+						row.add("")
+						if single {
+							row.add("")
+						}
+						continue
+					}
+					row.add(fmt.Sprintf("%.2fx [%.2f, %.2f]", s.ratio, s.lo, s.hi))
+					if single {
+						row.add(fmt.Sprintf("%.0f%%", 100*s.ratio*float64(g.procs[0])/float64(g.procs[i])))
+					}
+				}
+				if len(c.Configs) == 2 && i > 0 && speedups[0] != nil && speedups[1] != nil {
+					row.add("~   ")
+					change, p, err := speedupChange(speedups[0], speedups[1])
+					if err != nil {
+						row.add(fmt.Sprintf("(%s)", err))
+					} else {
+						if p < *flagAlpha {
+							row.cols[4] = fmt.Sprintf("%+.2f%%", 100*(change-1))
+						}
+						row.add(fmt.Sprintf("(p=%0.3f)", p))
+					}
+				}
+				row.trim()
+				rows = append(rows, row)
+				if i > 0 && len(row.cols) > 2 {
+					report = true
+				}
+			}
+			if report {
+				table = append(table, rows...)
+			}
+		}
+		if len(table) > 1 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, notes
+}
+
+// A speedup is the speedup of a benchmark over its baseline run,
+// estimated as the ratio of their geometric means.
+type speedup struct {
+	ratio, lo, hi float64
+
+	// terms are the log-scale means, variances, and sizes of the
+	// baseline and the run, with the sign each enters the log of
+	// the speedup with.
+	terms []logTerm
+}
+
+// A logTerm is a term in a linear combination of the means of
+// log-transformed samples.
+type logTerm struct {
+	coef, mean, variance, n float64
+}
+
+// newLogTerm returns the logTerm for the RValues of stat with the
+// given coefficient, or false if they are not all positive.
+func newLogTerm(stat *Benchstat, coef float64) (logTerm, bool) {
+	if stat == nil || len(stat.RValues) < 2 || stat.Min <= 0 {
+		return logTerm{}, false
+	}
+	ls := logSample(stat.RValues)
+	return logTerm{coef, ls.Mean(), ls.Variance(), float64(len(stat.RValues))}, true
+}
+
+// newSpeedup returns the speedup of stat over base, or nil if either
+// cannot be measured.
+func newSpeedup(base, stat *Benchstat) *speedup {
+	// For times, speedup is base/stat; for speeds, stat/base.
+	sign := 1.0
+	if stat != nil && unitOf(stat.Unit).kind == "speed" {
+		sign = -1
+	}
+	t1, ok1 := newLogTerm(base, sign)
+	t2, ok2 := newLogTerm(stat, -sign)
+	if !ok1 || !ok2 {
+		return nil
+	}
+	s := &speedup{terms: []logTerm{t1, t2}}
+	est, lo, hi, _, err := logContrast(s.terms)
+	if err != nil {
+		return nil
+	}
+	s.ratio, s.lo, s.hi = math.Exp(est), math.Exp(lo), math.Exp(hi)
+	return s
+}
+
+// speedupChange returns the ratio of speedup s2 to s1 and the p-value
+// of a test of the null hypothesis that they are equal.
+func speedupChange(s1, s2 *speedup) (ratio, p float64, err error) {
+	var terms []logTerm
+	for _, t := range s1.terms {
+		t.coef = -t.coef
+		terms = append(terms, t)
+	}
+	terms = append(terms, s2.terms...)
+	est, _, _, p, err := logContrast(terms)
+	return math.Exp(est), p, err
+}
+
+// logContrast estimates the linear combination of the log-scale means
+// in terms, with a confidence interval at level 1 - -alpha and the
+// p-value of a test of the null hypothesis that it is zero. Like
+// Welch's t-test, it does not assume the samples have equal
+// variances and approximates the degrees of freedom by the
+// Welch-Satterthwaite equation.
+func logContrast(terms []logTerm) (est, lo, hi, p float64, err error) {
+	var v, dofDenom float64
+	for _, t := range terms {
+		est += t.coef * t.mean
+		vi := t.coef * t.coef * t.variance / t.n
+		v += vi
+		dofDenom += vi * vi / (t.n - 1)
+	}
+	if v == 0 {
+		return est, est, est, -1, stats.ErrZeroVariance
+	}
+	dist := stats.TDist{V: v * v / dofDenom}
+	se := math.Sqrt(v)
+	w := stats.InvCDF(dist)(1-*flagAlpha/2) * se
+	p = 2 * (1 - dist.CDF(math.Abs(est)/se))
+	return est, est - w, est + w, p, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// A quantileFlag is a comma-separated list of percentiles, such as
+// "50,90,99", stored as quantiles in (0, 1).
+type quantileFlag []float64
+
+// quantileVar defines a quantileFlag flag with the given name and
+// usage, as flag.Float64 does for a float64 flag.
+func quantileVar(name, usage string) *quantileFlag {
+	f := new(quantileFlag)
+	flag.Var(f, name, usage)
+	return f
+}
+
+func (f *quantileFlag) String() string {
+	var list []string
+	for _, q := range *f {
+		list = append(list, strconv.FormatFloat(q*100, 'g', -1, 64))
+	}
+	return strings.Join(list, ",")
+}
+
+func (f *quantileFlag) Set(s string) error {
+	*f = nil
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "p")
+		x, err := strconv.ParseFloat(p, 64)
+		if err != nil || x <= 0 || x >= 100 {
+			return fmt.Errorf("bad percentile %q", p)
+		}
+		*f = append(*f, x/100)
+	}
+	return nil
+}
+
+// quantileTables returns tables comparing the quantiles qs of the
+// two configs of c.
+//
+// Quantiles are estimated with the Harrell-Davis estimator from all
+// of the samples, since discarding outliers would distort the tails,
+// and compared with stats.QuantileDifferenceTest.
+func quantileTables(c *Collection, qs []float64) ([][]*row, noteList) {
+	if len(c.Configs) != 2 {
+		log.Fatal("-quantiles requires exactly two inputs")
+	}
+	var tables [][]*row
+	var notes noteList
+	var marker string
+	before, after := c.Configs[0], c.Configs[1]
+	key := BenchKey{}
+	for _, key.Unit = range c.Units {
+		var table []*row
+		metric := metricOf(key.Unit)
+		for _, key.Benchmark = range c.Benchmarks {
+			key.Config = before
+			old := c.Stats[key]
+			key.Config = after
+			new := c.Stats[key]
+			if old == nil || new == nil {
+				continue
+			}
+			if len(table) == 0 {
+				if marker == "" {
+					marker = notes.add(fmt.Sprintf("Harrell-Davis estimates of each quantile, ± the half-width of a %g%% confidence interval", 100*(1-*flagAlpha)))
+				}
+				table = append(table, newRow("name", "quantile", "old "+metric+" "+marker, "new "+metric+" "+marker, "delta"))
+			}
+			x1 := *stats.Sample{Xs: old.Values}.Copy().Sort()
+			x2 := *stats.Sample{Xs: new.Values}.Copy().Sort()
+			for _, q := range qs {
+				q1, q2 := x1.HarrellDavis(q), x2.HarrellDavis(q)
+				scaler := newScaler(q1, key.Unit)
+				row := newRow(key.Benchmark, "p"+strconv.FormatFloat(q*100, 'g', -1, 64),
+					quantileCell(x1, q, scaler), quantileCell(x2, q, scaler), "~   ")
+				res, err := stats.QuantileDifferenceTest(x1, x2, q)
+				switch {
+				case err == stats.ErrZeroVariance:
+					row.add("(zero variance)")
+				case err == stats.ErrSampleSize:
+					row.add("(too few samples)")
+				case err != nil:
+					row.add(fmt.Sprintf("(%s)", err))
+				default:
+					if res.P < *flagAlpha {
+						row.cols[4] = formatDelta(key.Unit, q1, q2)
+					}
+					row.add(fmt.Sprintf("(p=%0.3f n=%d+%d)", res.P, res.N1, res.N2))
+				}
+				table = append(table, row)
+			}
+		}
+		if len(table) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, notes
+}
+
+// quantileCell formats the Harrell-Davis estimate of the q'th
+// quantile of x with its confidence interval.
+func quantileCell(x stats.Sample, q float64, scaler func(float64) string) string {
+	est := x.HarrellDavis(q)
+	s := scaler(est)
+	se := x.HarrellDavisStdErr(q)
+	if est == 0 || se != se {
+		return s + "     "
+	}
+	half := -stats.StdNormal.InvCDF(*flagAlpha/2) * se
+	return fmt.Sprintf("%s ±%3s", s, fmt.Sprintf("%.0f%%", half/est*100))
+}
@@ -0,0 +1,354 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// runMain implements the "benchstat run" subcommand, which runs the
+// benchmarks at two git revisions and compares the results.
+func runMain(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: benchstat run [options] old-rev [new-rev]\n")
+		fmt.Fprintf(os.Stderr, "\nRuns the benchmarks at old-rev and new-rev (default: the working tree)\n")
+		fmt.Fprintf(os.Stderr, "in temporary git worktrees and compares the results.\n")
+		fmt.Fprintf(os.Stderr, "options:\n")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	count := fs.Int("count", 10, "run each benchmark `n` times at each revision")
+	bench := fs.String("bench", ".", "run only benchmarks matching `regexp`")
+	benchtime := fs.String("benchtime", "", "pass -benchtime `d` to go test")
+	pkg := fs.String("pkg", "./...", "benchmark the packages matching `pattern`")
+	sequential := fs.Bool("sequential", false, "run batches of -count runs until every comparison is conclusive (different, or equivalent within -margin, default 1%)")
+	maxCount := fs.Int("max-count", 50, "in -sequential mode, stop after `n` runs at each revision")
+	// Accept the usual analysis flags too.
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Parse(args)
+	deltaTest := lookupDeltaTest()
+	if _, ok := spreads[*flagSpread]; fs.NArg() < 1 || fs.NArg() > 2 || *count < 1 || deltaTest == nil || !ok {
+		fs.Usage()
+	}
+	checkFlags()
+
+	r := &runner{bench: *bench, benchtime: *benchtime, pkg: *pkg}
+	if *sequential {
+		// A sequential run stops early on equivalence too, so
+		// it needs a margin even if -margin is not given.
+		margin := sequentialMargin
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "margin" {
+				margin = *flagMargin
+			}
+		})
+		s := &sequentialTest{
+			batch:     *count,
+			max:       *maxCount,
+			margin:    margin / 100,
+			deltaTest: deltaTest,
+			results:   make(map[BenchKey]*sequentialResult),
+		}
+		c, err := r.run(fs.Args(), s.max, s.look)
+		r.cleanup()
+		if err != nil {
+			log.Fatal(err)
+		}
+		printTables(s.tables(c))
+		return
+	}
+	c, err := r.run(fs.Args(), *count, nil)
+	r.cleanup()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, stat := range c.Stats {
+		stat.ComputeStats()
+	}
+	printTables(makeTables(c, deltaTest))
+	printMismatches(c)
+}
+
+// A runner runs benchmarks at git revisions.
+type runner struct {
+	bench, benchtime, pkg string
+
+	// configs are the names of the revisions being run, and
+	// dirs are the corresponding directories to run them in.
+	configs, dirs []string
+
+	// tmp is the temporary directory holding worktrees, which
+	// are removed when done.
+	tmp       string
+	worktrees []string
+}
+
+// run runs the benchmarks count times at each of revs and returns
+// the results. The runs of the different revisions are interleaved,
+// alternating their order every round, so that slow drift in the
+// machine's performance affects them all equally.
+//
+// If stop is non-nil, it is called after each round with the results
+// so far and the number of completed rounds, and run returns early if
+// it returns true.
+func (r *runner) run(revs []string, count int, stop func(c *Collection, n int) bool) (*Collection, error) {
+	if err := r.setup(revs); err != nil {
+		return nil, err
+	}
+	c := newCollection()
+	c.Configs = append(c.Configs, r.configs...)
+	for i := 0; i < count; i++ {
+		for j := range r.configs {
+			if i%2 == 1 {
+				j = len(r.configs) - 1 - j
+			}
+			if err := r.runOnce(c, j, i, count); err != nil {
+				return nil, err
+			}
+		}
+		if stop != nil && stop(c, i+1) {
+			break
+		}
+	}
+	return c, nil
+}
+
+// setup creates a temporary worktree for each revision. If only one
+// revision is given, the second config is the current working tree.
+func (r *runner) setup(revs []string) error {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %v", err)
+	}
+	root := strings.TrimSpace(string(out))
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cwd, err = filepath.EvalSymlinks(cwd)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, cwd)
+	if err != nil {
+		return err
+	}
+
+	r.tmp, err = ioutil.TempDir("", "benchstat-run-")
+	if err != nil {
+		return err
+	}
+	for i, rev := range revs {
+		dir := filepath.Join(r.tmp, fmt.Sprint(i))
+		if out, err := exec.Command("git", "worktree", "add", "--detach", dir, rev).CombinedOutput(); err != nil {
+			return fmt.Errorf("creating worktree for %s: %v\n%s", rev, err, out)
+		}
+		r.worktrees = append(r.worktrees, dir)
+		r.configs = append(r.configs, rev)
+		r.dirs = append(r.dirs, filepath.Join(dir, rel))
+	}
+	if len(revs) == 1 {
+		r.configs = append(r.configs, "working tree")
+		r.dirs = append(r.dirs, cwd)
+	}
+	return nil
+}
+
+// runOnce runs the benchmarks once for config j and adds the results
+// to c.
+func (r *runner) runOnce(c *Collection, j, i, count int) error {
+	args := []string{"test", "-run=^$", "-bench=" + r.bench, "-count=1"}
+	if r.benchtime != "" {
+		args = append(args, "-benchtime="+r.benchtime)
+	}
+	args = append(args, r.pkg)
+
+	log.Printf("run %d/%d of %s", i+1, count, r.configs[j])
+	cmd := exec.Command("go", args...)
+	cmd.Dir = r.dirs[j]
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			out = append(out, ee.Stderr...)
+		}
+		return fmt.Errorf("go %s at %s: %v\n%s", strings.Join(args, " "), r.configs[j], err, out)
+	}
+	readText(r.configs[j], string(out), c)
+	return nil
+}
+
+// cleanup removes the temporary worktrees.
+func (r *runner) cleanup() {
+	for _, dir := range r.worktrees {
+		if out, err := exec.Command("git", "worktree", "remove", "--force", dir).CombinedOutput(); err != nil {
+			log.Printf("removing worktree %s: %v\n%s", dir, err, out)
+		}
+	}
+	if r.tmp != "" {
+		os.RemoveAll(r.tmp)
+	}
+}
+
+// sequentialMargin is the default equivalence margin in percent for
+// run -sequential.
+const sequentialMargin = 1.0
+
+// A sequentialTest decides each comparison in a run as the results
+// accumulate. After every batch of runs, it tests each undecided
+// comparison for a significant difference and, if the margin is
+// nonzero, for equivalence within the margin, spending the overall
+// significance level across the looks with
+// stats.OBrienFlemingSpending so that the repeated looks do not
+// inflate the error rate.
+type sequentialTest struct {
+	batch, max int
+	margin     float64 // equivalence margin as a fraction of the old mean
+	deltaTest  func(old, new *Benchstat) (float64, error)
+
+	// spent is the significance level spent by previous looks.
+	spent float64
+
+	results map[BenchKey]*sequentialResult
+}
+
+// A sequentialResult is the decision for one comparison, along with
+// the statistics it was made from.
+type sequentialResult struct {
+	old, new *Benchstat
+	pval     float64
+	n        int
+	decision string // "different", "equivalent", or "inconclusive"
+}
+
+// look decides the comparisons in c that can be decided after n
+// rounds. It reports whether all comparisons have been decided.
+func (s *sequentialTest) look(c *Collection, n int) bool {
+	if n%s.batch != 0 && n != s.max {
+		return false
+	}
+	spent := stats.OBrienFlemingSpending(*flagAlpha, float64(n)/float64(s.max))
+	level := spent - s.spent
+	s.spent = spent
+
+	done := true
+	before, after := c.Configs[0], c.Configs[1]
+	key := BenchKey{}
+	for _, key.Unit = range c.Units {
+		for _, key.Benchmark = range c.Benchmarks {
+			if s.results[key] != nil {
+				continue
+			}
+			key.Config = before
+			old := snapshot(c.Stats[key])
+			key.Config = after
+			new := snapshot(c.Stats[key])
+			key.Config = ""
+			if old == nil || new == nil {
+				continue
+			}
+			res := &sequentialResult{old: old, new: new, pval: -1, n: n}
+			pval, err := s.deltaTest(old, new)
+			switch {
+			case err == stats.ErrSamplesEqual || err == stats.ErrZeroVariance:
+				res.decision = "equivalent"
+				if old.Mean != new.Mean {
+					res.decision = "different"
+				}
+			case err == nil && pval >= 0 && pval < level:
+				res.decision = "different"
+				res.pval = pval
+			case s.margin > 0 && equivalent(old, new, level, s.margin):
+				res.decision = "equivalent"
+				res.pval = pval
+			case n >= s.max:
+				res.decision = "inconclusive"
+				res.pval = pval
+			default:
+				done = false
+				continue
+			}
+			s.results[key] = res
+		}
+	}
+	if !done {
+		log.Printf("after %d runs, some comparisons are still inconclusive", n)
+	}
+	return done
+}
+
+// snapshot returns a copy of stat with its statistics computed from
+// the samples so far, or nil if stat is nil.
+func snapshot(stat *Benchstat) *Benchstat {
+	if stat == nil {
+		return nil
+	}
+	snap := &Benchstat{Unit: stat.Unit, Values: append([]float64(nil), stat.Values...), Iters: append([]int(nil), stat.Iters...)}
+	snap.ComputeStats()
+	return snap
+}
+
+// equivalent reports whether old and new are equivalent within
+// ±margin times the mean of old at the given significance level.
+func equivalent(old, new *Benchstat, level, margin float64) bool {
+	pval, err := equivTest(old, new, margin)
+	return err == nil && pval < level
+}
+
+// tables returns the tables reporting the decisions of s, along with
+// the notes their cells refer to.
+func (s *sequentialTest) tables(c *Collection) ([][]*row, noteList) {
+	var tables [][]*row
+	var notes noteList
+	key := BenchKey{}
+	for _, key.Unit = range c.Units {
+		var table []*row
+		metric := metricOf(key.Unit)
+		for _, key.Benchmark = range c.Benchmarks {
+			res := s.results[key]
+			if res == nil {
+				continue
+			}
+			if len(table) == 0 {
+				table = append(table, newRow("name", "old "+metric, "new "+metric, "delta"))
+			}
+			old, new := res.old, res.new
+			scaler := newScaler(old.Mean, old.Unit)
+			row := newRow(key.Benchmark, notes.cell(old, scaler, key.Benchmark, metric, c.Configs[0]), notes.cell(new, scaler, key.Benchmark, metric, c.Configs[1]), "~   ")
+			if res.decision == "different" {
+				row.cols[3] = formatDelta(old.Unit, old.Mean, new.Mean)
+			}
+			note := fmt.Sprintf("(%s", res.decision)
+			if res.decision == "equivalent" {
+				note += fmt.Sprintf(" within ±%g%%", s.margin*100)
+			}
+			if res.pval >= 0 {
+				note += fmt.Sprintf(" p=%0.3f", res.pval)
+			}
+			note += fmt.Sprintf(" n=%d+%d)", len(old.RValues), len(new.RValues))
+			row.add(note)
+			table = append(table, row)
+		}
+		if len(table) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, notes
+}
@@ -0,0 +1,276 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"rsc.io/benchstat/internal/go-moremath/scale"
+	"rsc.io/benchstat/internal/go-moremath/stats"
+)
+
+// A scalingGroup is a set of benchmarks that differ only in the
+// value of the -scaling key, such as Sort/n=10 and Sort/n=100.
+type scalingGroup struct {
+	name       string    // benchmark name with the key's value replaced by *
+	benchmarks []string  // the benchmarks in the group
+	sizes      []float64 // the key's value for each benchmark
+}
+
+// scalingGroups groups the benchmarks of c by the numeric value of
+// the sub-benchmark key, in order of first appearance. Benchmarks
+// without a numeric key are left out.
+func scalingGroups(c *Collection, key string) []*scalingGroup {
+	var groups []*scalingGroup
+	byName := make(map[string]*scalingGroup)
+	for _, benchmark := range c.Benchmarks {
+		name, size, ok := splitScalingKey(benchmark, key)
+		if !ok {
+			continue
+		}
+		g := byName[name]
+		if g == nil {
+			g = &scalingGroup{name: name}
+			byName[name] = g
+			groups = append(groups, g)
+		}
+		g.benchmarks = append(g.benchmarks, benchmark)
+		g.sizes = append(g.sizes, size)
+	}
+	if len(groups) == 0 {
+		log.Fatalf("no benchmark names have a numeric %s= component", key)
+	}
+	return groups
+}
+
+// splitScalingKey finds the sub-benchmark key=value in benchmark and
+// returns the benchmark's name with the value replaced by * and the
+// value. The -N procs suffix, if any, stays on the name.
+func splitScalingKey(benchmark, key string) (name string, size float64, ok bool) {
+	base, _ := splitProcs(benchmark)
+	suffix := benchmark[len(base):]
+	parts := strings.Split(base, "/")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, key+"=") {
+			continue
+		}
+		size, err := strconv.ParseFloat(part[len(key)+1:], 64)
+		if err != nil || size <= 0 {
+			return "", 0, false
+		}
+		parts[i] = key + "=*"
+		return strings.Join(parts, "/") + suffix, size, true
+	}
+	return "", 0, false
+}
+
+// scalingFit fits a line to the logarithms of the values of group in
+// config against the logarithms of the sizes. Its slope is the
+// exponent k of the best fitting power law, value ∝ sizeᵏ.
+func scalingFit(c *Collection, g *scalingGroup, unit, config string) (*stats.LinearRegressionResult, error) {
+	var xs, ys []float64
+	for i, benchmark := range g.benchmarks {
+		stat := c.Stats[BenchKey{Config: config, Benchmark: benchmark, Unit: unit}]
+		if stat == nil {
+			continue
+		}
+		for _, v := range stat.RValues {
+			if v > 0 {
+				xs = append(xs, math.Log(g.sizes[i]))
+				ys = append(ys, math.Log(v))
+			}
+		}
+	}
+	return stats.LinearRegression(xs, ys)
+}
+
+// scalingTables reports how each group of benchmarks scales with the
+// value of the sub-benchmark key, as the exponent of a power law
+// fitted to the values in each config. With two configs, it tests
+// whether the exponent changed.
+func scalingTables(c *Collection, key string) ([][]*row, noteList) {
+	var tables [][]*row
+	var notes noteList
+	groups := scalingGroups(c, key)
+	marker := notes.add(fmt.Sprintf("O(%s^k) ±w: k is the slope of a least-squares fit of log(value) against log(%s), with a %g%% confidence interval of half-width w.", key, key, 100*(1-*flagAlpha)))
+	for _, unit := range c.Units {
+		metric := metricOf(unit)
+		var table []*row
+		switch len(c.Configs) {
+		case 1:
+			table = append(table, newRow("name", metric+" "+marker))
+		case 2:
+			table = append(table, newRow("name", "old "+metric+" "+marker, "new "+metric, "delta"))
+		default:
+			hdr := newRow("name \\ " + metric + " " + marker)
+			for _, config := range c.Configs {
+				hdr.add(config)
+			}
+			table = append(table, hdr)
+		}
+		for _, g := range groups {
+			row := newRow(g.name)
+			fits := make([]*stats.LinearRegressionResult, len(c.Configs))
+			for i, config := range c.Configs {
+				fit, err := scalingFit(c, g, unit, config)
+				if err != nil {
+					row.add("")
+					continue
+				}
+				fits[i] = fit
+				lo, hi := fit.SlopeInterval(1 - *flagAlpha)
+				row.add(fmt.Sprintf("O(%s^%.2f) ±%.2f", key, fit.Slope, (hi-lo)/2))
+			}
+			switch {
+			case len(c.Configs) == 1 && fits[0] != nil:
+				row.add(fmt.Sprintf("(R²=%.3f n=%d)", fits[0].R2, fits[0].N))
+			case len(c.Configs) == 2 && fits[0] != nil && fits[1] != nil:
+				row.add("~   ")
+				t, err := stats.RegressionSlopeTest(fits[1], fits[0], stats.LocationDiffers)
+				switch {
+				case err == stats.ErrZeroVariance:
+					if fits[0].Slope != fits[1].Slope {
+						row.cols[3] = fmt.Sprintf("%+.2f", fits[1].Slope-fits[0].Slope)
+					}
+					row.add("(exact fits)")
+				case err != nil:
+					row.add(fmt.Sprintf("(%s)", err))
+				default:
+					if t.P < *flagAlpha {
+						row.cols[3] = fmt.Sprintf("%+.2f", fits[1].Slope-fits[0].Slope)
+					}
+					row.add(fmt.Sprintf("(p=%0.3f n=%d+%d)", t.P, t.N2, t.N1))
+				}
+			}
+			row.trim()
+			if len(row.cols) > 1 {
+				table = append(table, row)
+			}
+		}
+		if len(table) > 1 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, notes
+}
+
+// Dimensions of the plots printed by printScalingPlots.
+const (
+	plotWidth  = 60
+	plotHeight = 14
+)
+
+// printScalingPlots prints a log-log plot of each group of
+// benchmarks for each unit, with the mean values of each config and
+// their fitted lines and confidence bands.
+func printScalingPlots(c *Collection, key string) {
+	marks := "ox+*#@"
+	var buf bytes.Buffer
+	for _, unit := range c.Units {
+		for _, g := range scalingGroups(c, key) {
+			// Find the range of the plot.
+			xmin, xmax := stats.Bounds(g.sizes)
+			ymin, ymax := math.Inf(1), math.Inf(-1)
+			for _, benchmark := range g.benchmarks {
+				for _, config := range c.Configs {
+					if stat := c.Stats[BenchKey{Config: config, Benchmark: benchmark, Unit: unit}]; stat != nil && stat.Mean > 0 {
+						ymin, ymax = math.Min(ymin, stat.Mean), math.Max(ymax, stat.Mean)
+					}
+				}
+			}
+			if xmin == xmax || math.IsInf(ymin, 0) || ymin == ymax {
+				continue
+			}
+			sx, err1 := scale.NewLog(xmin, xmax, 10)
+			sy, err2 := scale.NewLog(ymin, ymax, 10)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			col := func(x float64) int { return int(math.Floor(sx.Map(x)*(plotWidth-1) + 0.5)) }
+			line := func(y float64) int { return plotHeight - 1 - int(math.Floor(sy.Map(y)*(plotHeight-1)+0.5)) }
+
+			grid := make([][]byte, plotHeight)
+			for i := range grid {
+				grid[i] = bytes.Repeat([]byte(" "), plotWidth)
+			}
+			legend := []string{}
+			for i, config := range c.Configs {
+				mark := marks[i%len(marks)]
+				legend = append(legend, fmt.Sprintf("%c %s", mark, config))
+				if fit, err := scalingFit(c, g, unit, config); err == nil {
+					for x := 0; x < plotWidth; x++ {
+						lx := math.Log(sx.Unmap(float64(x) / (plotWidth - 1)))
+						lo, hi := fit.PredictInterval(lx, 1-*flagAlpha)
+						for _, p := range []struct {
+							y    float64
+							mark byte
+						}{{lo, ':'}, {hi, ':'}, {fit.Predict(lx), '.'}} {
+							if l := line(math.Exp(p.y)); 0 <= l && l < plotHeight && (grid[l][x] == ' ' || grid[l][x] == ':') {
+								grid[l][x] = p.mark
+							}
+						}
+					}
+				}
+				for j, benchmark := range g.benchmarks {
+					if stat := c.Stats[BenchKey{Config: config, Benchmark: benchmark, Unit: unit}]; stat != nil && stat.Mean > 0 {
+						grid[line(stat.Mean)][col(g.sizes[j])] = mark
+					}
+				}
+			}
+
+			// Label the y axis at the tick marks.
+			ylabels := make([]string, plotHeight)
+			for _, y := range plotTicks(sy, ymin, ymax) {
+				ylabels[line(y)] = newScaler(y, unit)(y)
+			}
+			margin := 0
+			for _, l := range ylabels {
+				if len(l) > margin {
+					margin = len(l)
+				}
+			}
+
+			fmt.Fprintf(&buf, "\n%s %s\n", g.name, metricOf(unit))
+			for i, l := range grid {
+				fmt.Fprintf(&buf, "%*s |%s\n", margin, ylabels[i], strings.TrimRight(string(l), " "))
+			}
+			axis := bytes.Repeat([]byte("-"), plotWidth)
+			labels := bytes.Repeat([]byte(" "), plotWidth+10)
+			next := 0
+			for _, x := range plotTicks(sx, xmin, xmax) {
+				i := col(x)
+				axis[i] = '+'
+				if s := strconv.FormatFloat(x, 'g', -1, 64); i >= next {
+					copy(labels[i:], s)
+					next = i + len(s) + 1
+				}
+			}
+			fmt.Fprintf(&buf, "%*s +%s\n", margin, "", axis)
+			fmt.Fprintf(&buf, "%*s  %s\n", margin, "", strings.TrimRight(string(labels), " "))
+			fmt.Fprintf(&buf, "%*s  %s, . fit, : %g%% confidence band\n", margin, "", strings.Join(legend, ", "), 100*(1-*flagAlpha))
+		}
+	}
+	os.Stdout.Write(buf.Bytes())
+}
+
+// plotTicks returns the values at which to label an axis of the log
+// scale s covering [min, max]: the major ticks if there are at least
+// two of them, or else the minor ticks, or else min and max.
+func plotTicks(s scale.Log, min, max float64) []float64 {
+	major, minor := s.Ticks(5)
+	switch {
+	case len(major) >= 2:
+		return major
+	case len(minor) >= 2 && len(minor) <= 10:
+		return minor
+	}
+	return []float64{min, max}
+}
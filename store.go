@@ -0,0 +1,278 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A result store is an append-only text file of records. Each record
+// starts with a recordHeader line, followed by the record's
+// configuration labels and its benchmark lines in the usual Go
+// benchmark format. Since the header is ignored by readText, a store
+// can also be read as an ordinary benchmark file.
+const recordHeader = "# benchstat record"
+
+// A record is one set of benchmark results from a result store.
+type record struct {
+	labels map[string]string
+	lines  []string
+}
+
+// A labelFlag is a repeatable -label key=value flag.
+type labelFlag map[string]string
+
+func (f labelFlag) String() string {
+	var keys []string
+	for k := range f {
+		keys = append(keys, k+"="+f[k])
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func (f labelFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("label %q is not of the form key=value", s)
+	}
+	f[s[:i]] = s[i+1:]
+	return nil
+}
+
+// saveMain implements the "benchstat save" subcommand, which appends
+// benchmark files to a result store.
+func saveMain(args []string) {
+	fs := flag.NewFlagSet("save", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: benchstat save -store file [-label key=value ...] file ...\n")
+		fmt.Fprintf(os.Stderr, "\nAppends the results in the files to the store. The date and machine\n")
+		fmt.Fprintf(os.Stderr, "labels default to the current time and host name; other labels, such\n")
+		fmt.Fprintf(os.Stderr, "as commit, come only from the files or -label.\n")
+		fmt.Fprintf(os.Stderr, "options:\n")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	store := fs.String("store", "", "append results to the result store `file`")
+	labels := labelFlag{}
+	fs.Var(labels, "label", "add the configuration label `key=value` to the saved results (repeatable)")
+	fs.Parse(args)
+	if *store == "" || fs.NArg() < 1 {
+		fs.Usage()
+	}
+
+	// Default labels apply unless the files or -label flags
+	// provide their own.
+	defaults := map[string]string{
+		"date": time.Now().UTC().Format(time.RFC3339),
+	}
+	if host, err := os.Hostname(); err == nil {
+		defaults["machine"] = host
+	}
+
+	var buf bytes.Buffer
+	for _, file := range fs.Args() {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range splitRecords(string(text), defaults, labels) {
+			r.write(&buf)
+		}
+	}
+
+	f, err := os.OpenFile(*store, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// splitRecords splits the benchmark output in text into records. A new
+// record starts whenever a configuration label follows benchmark lines,
+// as happens between packages in the output of "go test ./...".
+// Labels carry over from one record to the next until they are
+// changed. Labels in text take precedence over those in defaults, and
+// labels in override take precedence over both. Unit metadata lines
+// apply to all of text, so every record gets a copy of them.
+func splitRecords(text string, defaults, override map[string]string) []*record {
+	var records []*record
+	labels := map[string]string{}
+	for k, v := range defaults {
+		labels[k] = v
+	}
+	var unitLines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "Unit ") {
+			unitLines = append(unitLines, line)
+		}
+	}
+	var lines []string
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		r := &record{labels: map[string]string{}, lines: append(unitLines[:len(unitLines):len(unitLines)], lines...)}
+		for k, v := range labels {
+			r.labels[k] = v
+		}
+		for k, v := range override {
+			r.labels[k] = v
+		}
+		records = append(records, r)
+		lines = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if k, v, ok := parseLabel(line); ok {
+			flush()
+			labels[k] = v
+			continue
+		}
+		if strings.HasPrefix(line, "Benchmark") {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return records
+}
+
+// write writes r in the result store format to buf.
+func (r *record) write(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "%s\n", recordHeader)
+	var keys []string
+	for k := range r.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s: %s\n", k, r.labels[k])
+	}
+	for _, line := range r.lines {
+		fmt.Fprintf(buf, "%s\n", line)
+	}
+}
+
+// readRecords reads all records from the result store file.
+func readRecords(file string) []*record {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var records []*record
+	var r *record
+	for _, line := range strings.Split(string(text), "\n") {
+		if line == recordHeader {
+			r = &record{labels: map[string]string{}}
+			records = append(records, r)
+			continue
+		}
+		if r == nil {
+			continue
+		}
+		if k, v, ok := parseLabel(line); ok {
+			r.labels[k] = v
+		} else if strings.HasPrefix(line, "Benchmark") || strings.HasPrefix(line, "Unit ") {
+			r.lines = append(r.lines, line)
+		}
+	}
+	return records
+}
+
+// readStore reads the records matching each query from the result
+// store file. Each query becomes one config of the returned Collection.
+//
+// A query is a space-separated list of terms, all of which must match
+// a record's labels. A term is one of key:pattern, which matches if
+// the label matches the path.Match pattern, or key<value and
+// key>value, which compare the label as a string. For example,
+//
+//	"pkg:example.com/x branch:main date<2026-10-11"
+//
+// Since labels compare as strings, RFC 3339 dates compare in time
+// order.
+func readStore(file string, queries []string) *Collection {
+	records := readRecords(file)
+	c := newCollection()
+	for _, query := range queries {
+		match, err := parseQuery(query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		c.Configs = append(c.Configs, query)
+		found := false
+		for _, r := range records {
+			if !match(r.labels) {
+				continue
+			}
+			found = true
+			// Read the record's labels along with its
+			// lines, so that -pair-label sees them.
+			var buf bytes.Buffer
+			r.write(&buf)
+			readText(query, buf.String(), c)
+		}
+		if !found {
+			log.Fatalf("query %q matched no results in %s", query, file)
+		}
+	}
+	return c
+}
+
+// parseQuery parses query and returns a function that reports whether
+// a record's labels match it.
+func parseQuery(query string) (func(labels map[string]string) bool, error) {
+	var terms []func(labels map[string]string) bool
+	for _, term := range strings.Fields(query) {
+		i := strings.IndexAny(term, ":<>")
+		if i <= 0 {
+			return nil, fmt.Errorf("malformed query term %q", term)
+		}
+		key, op, val := term[:i], term[i], term[i+1:]
+		if op == ':' {
+			if _, err := path.Match(val, ""); err != nil {
+				return nil, fmt.Errorf("malformed query term %q: %v", term, err)
+			}
+		}
+		terms = append(terms, func(labels map[string]string) bool {
+			have, ok := labels[key]
+			if !ok {
+				return false
+			}
+			switch op {
+			case '<':
+				return have < val
+			case '>':
+				return have > val
+			}
+			ok, _ = path.Match(val, have)
+			return ok
+		})
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	return func(labels map[string]string) bool {
+		for _, term := range terms {
+			if !term(labels) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// trendTables treats the configs of c as a history, ordered by
+// argument order or by the -trend-sort label, and reports the points
+// at which each benchmark's distribution changed.
+//
+// Change points are found by binary segmentation: within a segment
+// of the history, every split point is tested by comparing the
+// samples before the split with the samples after it using
+// deltaTest. If the most significant split passes a threshold
+// Bonferroni corrected for all split points in the history, it is
+// reported and both halves are searched recursively.
+func trendTables(c *Collection, deltaTest func(old, new *Benchstat) (float64, error)) ([][]*row, noteList) {
+	configs := c.Configs
+	if *flagTrendSort != "" {
+		configs = append([]string(nil), configs...)
+		sort.SliceStable(configs, func(i, j int) bool {
+			return c.Labels[configs[i]][*flagTrendSort] < c.Labels[configs[j]][*flagTrendSort]
+		})
+	}
+
+	var tables [][]*row
+	var notes noteList
+	key := BenchKey{}
+	for _, key.Unit = range c.Units {
+		var table []*row
+		metric := metricOf(key.Unit)
+		for _, key.Benchmark = range c.Benchmarks {
+			var seq []*Benchstat
+			var seqConfigs []string
+			for _, key.Config = range configs {
+				if stat := c.Stats[key]; stat != nil {
+					seq = append(seq, stat)
+					seqConfigs = append(seqConfigs, key.Config)
+				}
+			}
+			alpha := *flagAlpha / float64(len(seq)-1)
+			cps := changePoints(seq, 0, len(seq), alpha, deltaTest)
+			for i, cp := range cps {
+				if len(table) == 0 {
+					table = append(table, newRow("name", "changed at", "before "+metric, "after "+metric, "delta"))
+				}
+				// Summarize the stable stretches on
+				// either side of this change.
+				lo, hi := 0, len(seq)
+				if i > 0 {
+					lo = cps[i-1].index
+				}
+				if i+1 < len(cps) {
+					hi = cps[i+1].index
+				}
+				old, new := poolStats(seq[lo:cp.index]), poolStats(seq[cp.index:hi])
+				scaler := newScaler(old.Mean, old.Unit)
+				at := configLabel(c, seqConfigs[cp.index])
+				row := newRow(key.Benchmark, at, notes.cell(old, scaler, key.Benchmark, metric, "the runs before "+at), notes.cell(new, scaler, key.Benchmark, metric, "the runs from "+at))
+				row.add(formatDelta(old.Unit, old.Mean, new.Mean))
+				row.add(fmt.Sprintf("(p=%0.3f n=%d+%d)", cp.pval, len(old.RValues), len(new.RValues)))
+				table = append(table, row)
+			}
+		}
+		if len(table) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	return tables, notes
+}
+
+// configLabel returns the name to report for config in a history:
+// its "commit" label if it has one, or else the config name itself.
+func configLabel(c *Collection, config string) string {
+	if commit := c.Labels[config]["commit"]; commit != "" {
+		return commit
+	}
+	return config
+}
+
+// A changePoint is a point in a history at which a benchmark's
+// distribution changed.
+type changePoint struct {
+	index int     // index of the first entry after the change
+	pval  float64 // p-value of the split at index
+}
+
+// changePoints returns the change points in seq[lo:hi] that are
+// significant at level alpha, in order.
+func changePoints(seq []*Benchstat, lo, hi int, alpha float64, deltaTest func(old, new *Benchstat) (float64, error)) []changePoint {
+	if hi-lo < 2 {
+		return nil
+	}
+
+	var best *changePoint
+	for i := lo + 1; i < hi; i++ {
+		pval, err := deltaTest(poolSummary(seq[lo:i]), poolSummary(seq[i:hi]))
+		if err != nil || pval < 0 {
+			continue
+		}
+		if best == nil || pval < best.pval {
+			best = &changePoint{index: i, pval: pval}
+		}
+	}
+	if best == nil || best.pval >= alpha {
+		return nil
+	}
+
+	cps := changePoints(seq, lo, best.index, alpha, deltaTest)
+	cps = append(cps, *best)
+	return append(cps, changePoints(seq, best.index, hi, alpha, deltaTest)...)
+}
+
+// poolStats returns a Benchstat combining the samples of all of stats,
+// for reporting.
+func poolStats(stats []*Benchstat) *Benchstat {
+	pool := poolSummary(stats)
+	pool.computeModes()
+	return pool
+}
+
+// poolSummary is like poolStats, but computes only the statistics
+// the delta tests need, since changePoints pools every candidate
+// split. The order of the pooled samples reflects the configs they
+// came from, not the order of the runs, so neither checks it.
+func poolSummary(stats []*Benchstat) *Benchstat {
+	pool := &Benchstat{Unit: stats[0].Unit, Drift: -1}
+	for _, stat := range stats {
+		pool.Values = append(pool.Values, stat.Values...)
+		pool.Iters = append(pool.Iters, stat.Iters...)
+	}
+	pool.computeSummary()
+	return pool
+}
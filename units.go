@@ -0,0 +1,194 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// A unitInfo describes how to treat the values of a unit, as set by
+// the unit metadata lines in the input, such as
+//
+//	Unit p99-ns assume=exact better=lower tidy=ns/op
+//
+// Benchmarks report custom units with b.ReportMetric, and these lines
+// tell benchstat what the units mean.
+type unitInfo struct {
+	// exact reports whether the unit is a deterministic counter
+	// (assume=exact), whose values are compared directly instead of
+	// with a significance test.
+	exact bool
+
+	// better is -1 if lower values are better (better=lower), +1
+	// if higher values are better (better=higher), or 0 if the
+	// direction is unknown.
+	better int
+
+	// kind is "time", "bytes", or "speed" if values of the unit
+	// are converted to and displayed as ns/op, B/op, or MB/s,
+	// following its tidy= setting, or else "".
+	kind string
+
+	// factor converts values of the unit as read to their kind's
+	// base unit.
+	factor float64
+}
+
+// units holds the known units. It starts with the units the testing
+// package reports and collects the unit metadata lines of the input
+// as it is read. Like the flags, it is global because the code that
+// formats values has only their unit to go on.
+var units = map[string]*unitInfo{
+	"ns/op":     {better: -1, kind: "time", factor: 1},
+	"B/op":      {better: -1, kind: "bytes", factor: 1},
+	"allocs/op": {better: -1, factor: 1},
+	"MB/s":      {better: +1, kind: "speed", factor: 1},
+}
+
+// unitAttrs records the metadata set for each unit by the input, to
+// catch inputs that disagree about a unit.
+var unitAttrs = map[string]map[string]string{}
+
+// unitOf returns what is known about unit.
+func unitOf(unit string) unitInfo {
+	if u := units[unit]; u != nil {
+		return *u
+	}
+	return unitInfo{factor: 1}
+}
+
+// unitScales gives the kind of each time and byte unit and its size
+// in that kind's base unit, ns or B.
+var unitScales = map[string]struct {
+	kind  string
+	scale float64
+}{
+	"ns":  {"time", 1},
+	"us":  {"time", 1e3},
+	"µs":  {"time", 1e3},
+	"ms":  {"time", 1e6},
+	"s":   {"time", 1e9},
+	"sec": {"time", 1e9},
+	"B":   {"bytes", 1},
+	"kB":  {"bytes", 1e3},
+	"KB":  {"bytes", 1e3},
+	"MB":  {"bytes", 1e6},
+	"GB":  {"bytes", 1e9},
+	"KiB": {"bytes", 1 << 10},
+	"MiB": {"bytes", 1 << 20},
+	"GiB": {"bytes", 1 << 30},
+}
+
+// parseUnit returns the kind of unit and its size in the kind's base
+// unit, ns, B, or MB/s, or "" and 0 if it is not a time, size, or
+// speed. The measure is taken from after the last "-", so that names
+// like p99-ns work, and may be followed by "/op" or "/s".
+func parseUnit(unit string) (kind string, scale float64) {
+	perSecond := strings.HasSuffix(unit, "/s")
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "/op"), "/s")
+	if i := strings.LastIndex(unit, "-"); i >= 0 {
+		unit = unit[i+1:]
+	}
+	s, ok := unitScales[unit]
+	switch {
+	case !ok:
+		return "", 0
+	case perSecond && s.kind == "bytes":
+		return "speed", s.scale / 1e6
+	case perSecond:
+		return "", 0
+	}
+	return s.kind, s.scale
+}
+
+// addUnitLine records the unit metadata in the fields f of a "Unit"
+// line. Keys other than assume, better, and tidy are ignored, to
+// leave room for metadata meant for other tools. Since values are
+// converted to their tidy unit as they are read, c is used to check
+// that the unit has no values yet.
+func addUnitLine(f []string, c *Collection) {
+	if len(f) < 2 {
+		return
+	}
+	unit := f[1]
+	u := unitOf(unit)
+	attrs := unitAttrs[unit]
+	if attrs == nil {
+		attrs = make(map[string]string)
+		unitAttrs[unit] = attrs
+	}
+	for _, kv := range f[2:] {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			log.Fatalf("Unit %s: malformed metadata %q", unit, kv)
+		}
+		k, v := kv[:i], kv[i+1:]
+		switch k {
+		case "assume":
+			switch v {
+			case "nothing":
+				u.exact = false
+			case "exact":
+				u.exact = true
+			default:
+				log.Fatalf("Unit %s: assume=%s must be nothing or exact", unit, v)
+			}
+		case "better":
+			switch v {
+			case "lower":
+				u.better = -1
+			case "higher":
+				u.better = +1
+			default:
+				log.Fatalf("Unit %s: better=%s must be lower or higher", unit, v)
+			}
+		case "tidy":
+			// Values are stored in the kind's base unit,
+			// whichever unit of the kind tidy names.
+			kind, scale := parseUnit(unit)
+			if to, _ := parseUnit(v); kind == "" || kind != to {
+				log.Fatalf("Unit %s: cannot tidy to %s; tidy converts between units of time, size, or speed", unit, v)
+			}
+			u.kind, u.factor = kind, scale
+		default:
+			continue
+		}
+		if old, ok := attrs[k]; ok && old != v {
+			log.Fatalf("Unit %s: conflicting %s=%s and %s=%s", unit, k, old, k, v)
+		}
+		attrs[k] = v
+	}
+	if u.factor != unitOf(unit).factor {
+		for _, known := range c.Units {
+			if known == unit {
+				log.Fatalf("Unit %s: tidy= must come before any results in the unit", unit)
+			}
+		}
+	}
+	units[unit] = &u
+}
+
+// formatDelta formats the change from old to new, values of unit, as
+// a percentage, followed by whether it is better or worse if the
+// unit's direction is known.
+func formatDelta(unit string, old, new float64) string {
+	return fmt.Sprintf("%+.2f%%", (new/old-1)*100) + changeWord(unit, new-old)
+}
+
+// changeWord returns " better" or " worse" for a change of diff in a
+// value of unit, or "" if there is no change or the unit's direction
+// is unknown.
+func changeWord(unit string, diff float64) string {
+	better := unitOf(unit).better
+	switch {
+	case better == 0 || diff == 0:
+		return ""
+	case diff*float64(better) > 0:
+		return " better"
+	}
+	return " worse"
+}
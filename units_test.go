@@ -0,0 +1,29 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestUnitDirection(t *testing.T) {
+	check := func(unit string, old, new float64, want string) {
+		t.Helper()
+		if got := formatDelta(unit, old, new); got != want {
+			t.Errorf("formatDelta(%q, %v, %v) = %q, want %q", unit, old, new, got, want)
+		}
+	}
+	check("ns/op", 100, 110, "+10.00% worse")
+	check("MB/s", 100, 110, "+10.00% better")
+	check("ns/op", 100, 100, "+0.00%")
+
+	// Without metadata, the direction of a custom unit is
+	// unknown.
+	check("test-score", 100, 110, "+10.00%")
+	addUnitLine([]string{"Unit", "test-score", "better=higher"}, newCollection())
+	check("test-score", 100, 110, "+10.00% better")
+	check("test-score", 100, 90, "-10.00% worse")
+
+	addUnitLine([]string{"Unit", "test-misses", "better=lower"}, newCollection())
+	check("test-misses", 100, 110, "+10.00% worse")
+}